@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs/service"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	log "github.com/sirupsen/logrus"
+)
+
+// runDoctor walks through what the collector does at startup and reports each
+// step, stopping at the first failure
+func runDoctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := configFlag(flags)
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout for each network step")
+	flags.Parse(args)
+
+	// Keep library logging out of the report
+	log.SetLevel(log.ErrorLevel)
+
+	var sequencer service.Sequencer
+	var sequencerInfo *peer.AddrInfo
+	steps := []struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}{
+		{"load config", func(context.Context) (string, error) {
+			settings, err := config.Load(*configPath)
+			if err != nil {
+				return "", err
+			}
+			config.Set(settings)
+			return fmt.Sprintf("data market %s", settings.DataMarketAddress), nil
+		}},
+		{"resolve sequencer", func(context.Context) (string, error) {
+			var err error
+			if sequencer, err = service.ResolveSequencer(); err != nil {
+				return "", err
+			}
+			maddr, err := ma.NewMultiaddr(sequencer.Maddr)
+			if err != nil {
+				return "", fmt.Errorf("invalid sequencer multiaddr %q: %w", sequencer.Maddr, err)
+			}
+			if sequencerInfo, err = peer.AddrInfoFromP2pAddr(maddr); err != nil {
+				return "", fmt.Errorf("invalid sequencer multiaddr %q: %w", sequencer.Maddr, err)
+			}
+			return sequencer.Maddr, nil
+		}},
+		{"create libp2p host", func(context.Context) (string, error) {
+			if err := service.CreateLibP2pHost(); err != nil {
+				return "", err
+			}
+			return service.SequencerHostConn.ID().String(), nil
+		}},
+		{"dial sequencer", func(ctx context.Context) (string, error) {
+			if err := service.SequencerHostConn.Connect(ctx, *sequencerInfo); err != nil {
+				return "", err
+			}
+			conns := service.DescribeConnections(service.SequencerHostConn, sequencerInfo.ID)
+			if len(conns) == 0 {
+				return "", errors.New("connected but no connection is open")
+			}
+			c := conns[0]
+			path := "direct"
+			if c.Relayed {
+				path = "relayed"
+			}
+			return fmt.Sprintf("%s %s via %s (security %s, muxer %s)", path, c.Direction, c.RemoteAddr, c.Security, c.Muxer), nil
+		}},
+		{"open " + service.CollectProtocol + " stream", func(ctx context.Context) (string, error) {
+			stream, err := service.SequencerHostConn.NewStream(ctx, sequencerInfo.ID, service.CollectProtocol)
+			if err != nil {
+				return "", err
+			}
+			if err := stream.Close(); err != nil {
+				return "", fmt.Errorf("stream opened but failed to close: %w", err)
+			}
+			return fmt.Sprintf("stream %s opened and closed", stream.ID()), nil
+		}},
+	}
+
+	defer func() {
+		if service.SequencerHostConn != nil {
+			service.SequencerHostConn.Close()
+		}
+	}()
+
+	for _, step := range steps {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		start := time.Now()
+		detail, err := step.run(ctx)
+		cancel()
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", step.name, err)
+			return 1
+		}
+		fmt.Printf("✅ %s (%v): %s\n", step.name, time.Since(start).Round(time.Millisecond), detail)
+	}
+	fmt.Println("All checks passed")
+	return 0
+}
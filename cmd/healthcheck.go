@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// runHealthcheck probes the running collector for container health checks.
+// It queries the admin /healthz endpoint, or only checks that the gRPC port
+// accepts connections when the admin API is disabled.
+func runHealthcheck(args []string) int {
+	adminPort := envOr("ADMIN_PORT", "9091")
+	grpcPort := envOr("LOCAL_COLLECTOR_PORT", "50051")
+
+	flags := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	url := flags.String("url", "http://127.0.0.1:"+adminPort+"/healthz", "admin health endpoint")
+	timeout := flags.Duration("timeout", 3*time.Second, "probe timeout")
+	flags.Parse(args)
+
+	if os.Getenv("ADMIN_ENABLED") == "false" {
+		conn, err := net.DialTimeout("tcp", "127.0.0.1:"+grpcPort, *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unhealthy: gRPC port not reachable: %v\n", err)
+			return 1
+		}
+		conn.Close()
+		fmt.Println("healthy (gRPC port reachable)")
+		return 0
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(*url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: %v\n", err)
+		return 1
+	}
+	defer resp.Body.Close()
+
+	var report struct {
+		Healthy  bool     `json:"healthy"`
+		Version  string   `json:"version"`
+		Problems []string `json:"problems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: invalid health response: %v\n", err)
+		return 1
+	}
+	if resp.StatusCode != http.StatusOK || !report.Healthy {
+		fmt.Fprintf(os.Stderr, "unhealthy: %v\n", report.Problems)
+		return 1
+	}
+	fmt.Printf("healthy (%s)\n", report.Version)
+	return 0
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
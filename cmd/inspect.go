@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"proto-snapshot-server/pkgs/service"
+
+	"github.com/google/uuid"
+)
+
+// runInspect decodes a frame as written to the sequencer stream: either a
+// single submission (36-byte ID followed by JSON) or a batch frame
+func runInspect(args []string) int {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: inspect [file]   (reads stdin when no file is given)")
+	}
+	flags.Parse(args)
+
+	var data []byte
+	var err error
+	if flags.NArg() > 0 {
+		data, err = os.ReadFile(flags.Arg(0))
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read frame: %v\n", err)
+		return 1
+	}
+
+	payloads := [][]byte{data}
+	if bytes.HasPrefix(data, []byte(service.BatchFrameMagic)) {
+		if payloads, err = service.DecodeBatchFrame(data); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid batch frame: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Batch frame with %d submissions (%d bytes)\n", len(payloads), len(data))
+	}
+
+	failed := false
+	for i, payload := range payloads {
+		if err := printPayload(i, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Payload %d: %v\n", i, err)
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printPayload(index int, payload []byte) error {
+	const idLen = 36
+	if len(payload) < idLen {
+		return fmt.Errorf("payload of %d bytes is shorter than a submission ID", len(payload))
+	}
+	id, err := uuid.ParseBytes(payload[:idLen])
+	if err != nil {
+		return fmt.Errorf("invalid submission ID: %w", err)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, payload[idLen:], "", "  "); err != nil {
+		return fmt.Errorf("invalid submission JSON: %w", err)
+	}
+	fmt.Printf("[%d] submission %s\n%s\n", index, id, pretty.String())
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// runKeygen writes a new hex-encoded libp2p Ed25519 private key to the file
+// the collector loads the relayer key from
+func runKeygen(args []string) int {
+	flags := flag.NewFlagSet("keygen", flag.ExitOnError)
+	out := flags.String("out", "/keys/key.txt", "key file to create")
+	force := flags.Bool("force", false, "overwrite an existing key file")
+	flags.Parse(args)
+
+	if _, err := os.Stat(*out); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "%s already exists; use -force to replace it\n", *out)
+		return 1
+	}
+
+	privKey, pubKey, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate key: %v\n", err)
+		return 1
+	}
+	raw, err := crypto.MarshalPrivateKey(privKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode key: %v\n", err)
+		return 1
+	}
+	peerID, err := peer.IDFromPublicKey(pubKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to derive peer ID: %v\n", err)
+		return 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*out), 0o700); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create key directory: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*out, []byte(hex.EncodeToString(raw)), 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write key file: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %s\nPeer ID: %s\n", *out, peerID)
+	return 0
+}
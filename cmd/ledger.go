@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"proto-snapshot-server/pkgs/service"
+	"text/tabwriter"
+	"time"
+)
+
+// runLedger queries the submission ledger, through the admin API of the
+// running collector or, with -db, straight from the ledger file of a stopped
+// one
+func runLedger(args []string) int {
+	flags := flag.NewFlagSet("ledger", flag.ExitOnError)
+	adminURL := flags.String("admin", "http://127.0.0.1:"+envOr("ADMIN_PORT", "9091"), "admin API of the running collector")
+	token := flags.String("token", os.Getenv("ADMIN_TOKEN"), "admin API token, when the collector requires one")
+	dbPath := flags.String("db", "", "read this ledger file directly instead of asking the running collector")
+	epoch := flags.String("epoch", "", "only submissions for this epoch")
+	slot := flags.String("slot", "", "only submissions from this slot")
+	project := flags.String("project", "", "only submissions for this project")
+	outcome := flags.String("outcome", "", "only submissions that succeeded or failed")
+	since := flags.String("since", "", "only submissions received since this RFC 3339 time or duration ago, e.g. 1h")
+	until := flags.String("until", "", "only submissions received until this RFC 3339 time or duration ago")
+	limit := flags.Int("limit", 0, "maximum number of records (default 1000)")
+	asJSON := flags.Bool("json", false, "print records as JSON")
+	flags.Parse(args)
+
+	values := url.Values{}
+	for name, value := range map[string]string{
+		"epoch": *epoch, "slot": *slot, "project": *project, "outcome": *outcome,
+		"since": *since, "until": *until,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	if *limit > 0 {
+		values.Set("limit", fmt.Sprint(*limit))
+	}
+
+	var records []service.LedgerRecord
+	var err error
+	if *dbPath != "" {
+		records, err = queryLedgerFile(*dbPath, values)
+	} else {
+		records, err = queryLedgerAPI(*adminURL, *token, values)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ledger query failed: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(records)
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "RECEIVED\tEPOCH\tSLOT\tPROJECT\tCID\tOUTCOME\tERROR")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", r.ReceivedAt.Format(time.RFC3339),
+			r.EpochID, r.SlotID, r.ProjectID, r.SnapshotCID, r.Outcome, r.Error)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "%d record(s)\n", len(records))
+	return 0
+}
+
+func queryLedgerFile(path string, values url.Values) ([]service.LedgerRecord, error) {
+	q, err := service.ParseLedgerQuery(values)
+	if err != nil {
+		return nil, err
+	}
+	l, err := service.OpenLedger(path, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w (is the collector running? query it without -db)", err)
+	}
+	defer l.Close()
+	return l.Query(q)
+}
+
+func queryLedgerAPI(adminURL, token string, values url.Values) ([]service.LedgerRecord, error) {
+	req, err := http.NewRequest(http.MethodGet, adminURL+"/ledger?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return nil, fmt.Errorf("%s: %s", resp.Status, result.Error)
+	}
+	var records []service.LedgerRecord
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid ledger response: %w", err)
+	}
+	return records, nil
+}
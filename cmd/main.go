@@ -1,57 +1,70 @@
 package main
 
 import (
-	"context"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
 	"proto-snapshot-server/config"
-	"proto-snapshot-server/pkgs/helpers"
-	"proto-snapshot-server/pkgs/service"
-	"sync"
-	"syscall"
-
-	log "github.com/sirupsen/logrus"
+	"sort"
+	"strings"
 )
 
-func main() {
-	// Load the config object
-	config.LoadConfig()
+// command is a CLI subcommand; run returns the process exit code
+type command struct {
+	summary string
+	run     func(args []string) int
+}
 
-	// Initiate logger
-	helpers.InitLogger()
+var commands = map[string]command{
+	"serve":       {"run the collector (default)", runServe},
+	"doctor":      {"check config and connectivity to the sequencer step by step", runDoctor},
+	"submit":      {"send a test snapshot submission to a running collector", runSubmit},
+	"keygen":      {"create the relayer private key file", runKeygen},
+	"inspect":     {"decode a frame written to the sequencer stream", runInspect},
+	"ledger":      {"query the record of submissions the collector handled", runLedger},
+	"healthcheck": {"exit non-zero unless the running collector is healthy", runHealthcheck},
+	"version":     {"print the collector version", runVersion},
+}
 
-	// Initialize the service
-	if err := service.InitializeService(); err != nil {
-		log.Errorf("Failed to initialize service: %v", err)
+func main() {
+	// Without a subcommand the collector serves, as it always has
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	// Create a new submission server instance
-	server := service.NewMsgServerImplV2()
-
-	// Set up signal handling for graceful shutdown
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-
-	// Create context for connection management
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Start connection refresh loop
-	go service.StartConnectionRefreshLoop(ctx)
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		service.StartSubmissionServer(server)
-	}()
+	if name == "help" {
+		usage()
+		os.Exit(0)
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	os.Exit(cmd.run(args))
+}
 
-	// Wait for termination signal
-	sig := <-sigs
-	log.Infof("✅ Received signal: %s. Shutting down gracefully...", sig)
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun '<command> -h' for the flags of a command.")
+}
 
-	// Perform cleanup
-	service.GracefulShutdownServer(server)
+// configFlag registers the shared --config flag on flags
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file; environment variables override its values")
+}
 
-	wg.Wait()
+func runVersion(args []string) int {
+	fmt.Println(config.Version)
+	return 0
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs/helpers"
+	"proto-snapshot-server/pkgs/service"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// runServe runs the collector until it receives a termination signal
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := configFlag(flags)
+	printConfig := flags.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flags.Parse(args)
+
+	// Load the config object
+	config.LoadConfig(*configPath)
+
+	if *printConfig {
+		out, err := yaml.Marshal(config.Current().Redacted())
+		if err != nil {
+			log.Fatalf("Failed to render config: %v", err)
+		}
+		fmt.Print(string(out))
+		return 0
+	}
+
+	// Initiate logger
+	helpers.InitLogger()
+	log.Infof("Starting snapshotter local collector %s", config.Version)
+
+	// Initiate tracing before any spans are started
+	shutdownTracing, err := service.InitTracing(context.Background())
+	if err != nil {
+		log.Errorf("Failed to initialize tracing: %v", err)
+	}
+
+	// Report collector-side failures to Powerloom when configured
+	if config.Current().PowerloomReportingUrl != "" {
+		service.InitializeReportingService(config.Current())
+		log.Infof("📣 Issue reporting enabled: %s", config.Current().PowerloomReportingUrl)
+	}
+	if err := service.InitializeWebhooks(config.Current()); err != nil {
+		log.Errorf("Failed to initialize webhooks: %v", err)
+	}
+
+	// Keep a local record of every submission handled
+	if config.Current().LedgerFile != "" {
+		if err := service.InitLedger(config.Current().LedgerFile, config.Current().LedgerRetention, config.Current().LedgerQueueSize); err != nil {
+			log.Errorf("Failed to open submission ledger: %v", err)
+		}
+	}
+
+	// Follow the chain head to enforce request deadlines
+	if err := service.InitChainHead(config.Current()); err != nil {
+		log.Errorf("Failed to start chain head provider: %v", err)
+	}
+
+	// Initialize the service
+	if err := service.InitializeService(); err != nil {
+		log.Errorf("Failed to initialize service: %v", err)
+	}
+
+	// Create a new submission server instance
+	server := service.NewMsgServerImplV2()
+
+	// Set up signal handling for graceful shutdown
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
+
+	// Create context for connection management
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start connection refresh loop
+	go service.StartConnectionRefreshLoop(ctx)
+
+	// Send liveness heartbeats when enabled
+	go service.StartHeartbeatLoop(ctx, server)
+
+	// Expose Prometheus metrics
+	go service.StartMetricsServer()
+
+	// Expose the admin/status API
+	go service.StartAdminServer(server)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		service.StartSubmissionServer(server)
+	}()
+
+	// Wait for termination signal; SIGHUP reloads the config and re-reads
+	// the log level overrides
+	sig := <-sigs
+	for sig == syscall.SIGHUP {
+		log.Info("🔄 Received SIGHUP, reloading configuration")
+		if err := service.ReloadConfig(server, *configPath); err != nil {
+			log.Errorf("Failed to reload config: %v", err)
+		}
+		if err := helpers.ApplyLevelOverridesFile(config.Current().LogLevelOverridesFile, config.Current().LogLevelOverrideTTL); err != nil {
+			log.Errorf("Failed to apply log level overrides: %v", err)
+		}
+		sig = <-sigs
+	}
+	log.Infof("✅ Received signal: %s. Shutting down gracefully...", sig)
+
+	// Perform cleanup
+	service.GracefulShutdownServer(server)
+	service.StopReportingService()
+	service.StopWebhooks()
+	service.CloseLedger()
+	service.StopChainHead()
+
+	wg.Wait()
+
+	// Flush buffered spans
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Warnf("Failed to flush traces: %v", err)
+	}
+	return 0
+}
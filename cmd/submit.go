@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"proto-snapshot-server/pkgs"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// runSubmit sends one SnapshotSubmission to a running collector, built from
+// flags or read from a JSON file
+func runSubmit(args []string) int {
+	port := os.Getenv("LOCAL_COLLECTOR_PORT")
+	if port == "" {
+		port = "50051"
+	}
+
+	flags := flag.NewFlagSet("submit", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:"+port, "collector gRPC address")
+	file := flags.String("file", "", "JSON file holding a SnapshotSubmission; overrides the field flags")
+	epoch := flags.Uint64("epoch", 0, "epoch ID (0 submits a simulation snapshot)")
+	slot := flags.Uint64("slot", 1, "slot ID")
+	project := flags.String("project", "test:0x0000000000000000000000000000000000000000:collector-cli", "project ID")
+	cid := flags.String("cid", "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku", "snapshot CID")
+	deadline := flags.Uint64("deadline", 0, "submission deadline block")
+	signature := flags.String("signature", "0x"+strings.Repeat("00", 65), "request signature, 65 hex-encoded bytes")
+	header := flags.String("header", "0x"+strings.Repeat("00", 32), "request header, a 32-byte hex-encoded block hash")
+	dataMarket := flags.String("data-market", "", "data market address")
+	timeout := flags.Duration("timeout", 10*time.Second, "call timeout")
+	flags.Parse(args)
+
+	submission := &pkgs.SnapshotSubmission{
+		Request: &pkgs.Request{
+			SlotId:      *slot,
+			Deadline:    *deadline,
+			SnapshotCid: *cid,
+			EpochId:     *epoch,
+			ProjectId:   *project,
+		},
+		Signature:  *signature,
+		Header:     *header,
+		DataMarket: *dataMarket,
+	}
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read submission file: %v\n", err)
+			return 1
+		}
+		submission = &pkgs.SnapshotSubmission{}
+		if err := protojson.Unmarshal(data, submission); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse submission file: %v\n", err)
+			return 1
+		}
+	}
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %s: %v\n", *addr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := pkgs.NewSubmissionClient(conn).SubmitSnapshot(ctx, submission)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Submission failed after %v: %v\n", time.Since(start).Round(time.Millisecond), err)
+		return 1
+	}
+	fmt.Printf("%s (%v)\n", resp.GetMessage(), time.Since(start).Round(time.Millisecond))
+	return 0
+}
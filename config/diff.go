@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes one setting that differs between two Settings
+type Change struct {
+	Field string // YAML key of the setting
+	Old   string
+	New   string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.Old, c.New)
+}
+
+// Diff lists the settings that differ between old and new. Values are
+// compared as they are but shown redacted, so a changed secret is listed
+// without revealing it.
+func Diff(old, new *Settings) []Change {
+	oldValue, newValue := reflect.ValueOf(*old), reflect.ValueOf(*new)
+	oldShown, newShown := reflect.ValueOf(old.Redacted()), reflect.ValueOf(new.Redacted())
+	settingsType := oldValue.Type()
+
+	var changes []Change
+	for i := 0; i < settingsType.NumField(); i++ {
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		changes = append(changes, Change{
+			Field: yamlKey(settingsType.Field(i)),
+			Old:   fmt.Sprint(oldShown.Field(i).Interface()),
+			New:   fmt.Sprint(newShown.Field(i).Interface()),
+		})
+	}
+	return changes
+}
+
+// Keep copies the settings named by their YAML keys from old into s
+func (s *Settings) Keep(old *Settings, fields ...string) {
+	target := reflect.ValueOf(s).Elem()
+	source := reflect.ValueOf(old).Elem()
+	settingsType := target.Type()
+
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	for i := 0; i < settingsType.NumField(); i++ {
+		if keep[yamlKey(settingsType.Field(i))] {
+			target.Field(i).Set(source.Field(i))
+		}
+	}
+}
+
+func yamlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -1,87 +1,462 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
-var SettingsObj *Settings
+// current holds the settings in effect. A reload publishes a new Settings
+// value instead of changing the one in use, so readers always see a
+// consistent snapshot.
+var current atomic.Pointer[Settings]
+
+// Current returns the settings in effect. The result must not be modified.
+func Current() *Settings {
+	return current.Load()
+}
+
+// Set makes settings the ones in effect
+func Set(settings *Settings) {
+	current.Store(settings)
+}
+
+// Version is the collector build version, set at build time with
+// -ldflags "-X proto-snapshot-server/config.Version=..."
+var Version = "dev"
 
 type Settings struct {
-	LogLevel               string
-	SequencerID            string
-	RelayerRendezvousPoint string
-	ClientRendezvousPoint  string
-	RelayerPrivateKey      string
-	PowerloomReportingUrl  string
-	SignerAccountAddress   string
-	PortNumber             string
-	TrustedRelayersListUrl string
-	DataMarketAddress      string
-	MaxStreamPoolSize      int
-	DataMarketInRequest    bool
+	LogLevel               string        `yaml:"log_level"`
+	SequencerID            string        `yaml:"sequencer_id"`
+	RelayerRendezvousPoint string        `yaml:"relayer_rendezvous_point"`
+	ClientRendezvousPoint  string        `yaml:"client_rendezvous_point"`
+	RelayerPrivateKey      string        `yaml:"relayer_private_key"`
+	PowerloomReportingUrl  string        `yaml:"powerloom_reporting_url"`
+	ReportingTimeout       time.Duration `yaml:"reporting_timeout"`
+	SignerAccountAddress   string        `yaml:"signer_account_address"`
+	PortNumber             string        `yaml:"port_number"`
+	TrustedRelayersListUrl string        `yaml:"trusted_relayers_list_url"`
+	SequencersListUrl      string        `yaml:"sequencers_list_url"`
+	DataMarketAddress      string        `yaml:"data_market_address"`
+	MaxStreamPoolSize      int           `yaml:"max_stream_pool_size"`
+	DataMarketInRequest    bool          `yaml:"data_market_in_request"`
 
 	// Stream Pool Configuration
-	StreamHealthCheckTimeout time.Duration
-	StreamWriteTimeout       time.Duration
-	MaxWriteRetries          int
-	MaxConcurrentWrites      int
-	MaxStreamQueueSize       int
-	WorkerPoolSize           int
+	StreamHealthCheckTimeout time.Duration `yaml:"stream_health_check_timeout"`
+	StreamWriteTimeout       time.Duration `yaml:"stream_write_timeout"`
+	MaxWriteRetries          int           `yaml:"max_write_retries"`
+	MaxConcurrentWrites      int           `yaml:"max_concurrent_writes"`
+	MaxStreamQueueSize       int           `yaml:"max_stream_queue_size"`
+	WorkerPoolSize           int           `yaml:"worker_pool_size"`
+
+	// Shape checks on incoming submissions. ValidationAction "flag" reports
+	// malformed submissions and still forwards them, "reject" refuses them and
+	// "off" skips the checks. MaxSubmissionBytes is the largest encoded
+	// submission allowed, 0 for no limit.
+	ValidationAction   string `yaml:"validation_action"`
+	MaxSubmissionBytes int    `yaml:"max_submission_bytes"`
+
+	// Upper bound for a submission when the client call carries no deadline
+	SubmissionTimeout time.Duration `yaml:"submission_timeout"`
+
+	// Async mode acknowledges submissions once queued for the worker pool
+	AsyncSubmissionMode bool `yaml:"async_submission_mode"`
+	SubmissionQueueSize int  `yaml:"submission_queue_size"`
+
+	// Batching coalesces submissions into framed batches, one per stream write
+	BatchSubmissions bool          `yaml:"batch_submissions"`
+	BatchWindow      time.Duration `yaml:"batch_window"`
+	BatchMaxCount    int           `yaml:"batch_max_count"`
+	BatchMaxBytes    int           `yaml:"batch_max_bytes"`
+
+	// Stream strategy: "pooled" reuses long-lived streams, "ephemeral" writes
+	// each frame on its own stream and half-closes it
+	StreamStrategy           string        `yaml:"stream_strategy"`
+	EphemeralReadResponse    bool          `yaml:"ephemeral_read_response"`
+	EphemeralResponseTimeout time.Duration `yaml:"ephemeral_response_timeout"`
+
+	// Priority lanes: weighted admission of current-epoch, late and
+	// simulation submissions to the write slots
+	LaneWeightCurrent     int `yaml:"lane_weight_current"`
+	LaneWeightLate        int `yaml:"lane_weight_late"`
+	LaneWeightSimulation  int `yaml:"lane_weight_simulation"`
+	LaneQueueSize         int `yaml:"lane_queue_size"`
+	SimulationMaxInFlight int `yaml:"simulation_max_in_flight"`
+
+	// Chain head used to enforce request deadlines, which are block numbers.
+	// The provider is "none", "rpc" (polls eth_blockNumber on ChainRPCURL) or
+	// "static" (starts at ChainStaticBlock and is moved through the admin
+	// API). Past-deadline submissions are flagged or, with DeadlineAction
+	// "reject", refused; those within DeadlineUrgentBlocks of their deadline
+	// are written ahead of every other lane.
+	ChainHeadProvider    string        `yaml:"chain_head_provider"`
+	ChainRPCURL          string        `yaml:"chain_rpc_url"`
+	ChainPollInterval    time.Duration `yaml:"chain_poll_interval"`
+	ChainStaticBlock     int           `yaml:"chain_static_block"`
+	DeadlineAction       string        `yaml:"deadline_action"`
+	DeadlineUrgentBlocks int           `yaml:"deadline_urgent_blocks"`
+
+	// Prometheus metrics endpoint. Off by default; it listens on loopback
+	// unless MetricsHost says otherwise.
+	MetricsEnabled bool   `yaml:"metrics_enabled"`
+	MetricsHost    string `yaml:"metrics_host"`
+	MetricsPort    string `yaml:"metrics_port"`
+
+	// OpenTelemetry tracing
+	TracingEnabled       bool   `yaml:"tracing_enabled"`
+	TracingExporter      string `yaml:"tracing_exporter"`
+	TracingEndpoint      string `yaml:"tracing_endpoint"`
+	TracingInsecure      bool   `yaml:"tracing_insecure"`
+	TracingFile          string `yaml:"tracing_file"`
+	TracingSamplePercent int    `yaml:"tracing_sample_percent"`
+
+	// Admin/status HTTP API. It listens on loopback unless AdminHost says
+	// otherwise; when AdminToken is set, every endpoint but /healthz requires
+	// it as a bearer token.
+	AdminEnabled bool   `yaml:"admin_enabled"`
+	AdminHost    string `yaml:"admin_host"`
+	AdminPort    string `yaml:"admin_port"`
+	AdminToken   string `yaml:"admin_token"`
+
+	// Logging output: "text" or "json", optionally mirrored to a rotated file.
+	// Success logs are emitted for one in every LogSuccessSampleEvery writes.
+	LogFormat             string `yaml:"log_format"`
+	LogFile               string `yaml:"log_file"`
+	LogFileMaxSizeMB      int    `yaml:"log_file_max_size_mb"`
+	LogFileMaxAgeDays     int    `yaml:"log_file_max_age_days"`
+	LogFileMaxBackups     int    `yaml:"log_file_max_backups"`
+	LogSuccessSampleEvery int    `yaml:"log_success_sample_every"`
+
+	// Temporary per-subsystem log levels, applied from the overrides file on
+	// SIGHUP or through the admin API, revert after LogLevelOverrideTTL
+	LogLevelOverridesFile string        `yaml:"log_level_overrides_file"`
+	LogLevelOverrideTTL   time.Duration `yaml:"log_level_override_ttl"`
 
 	// Connection management settings
-	ConnectionRefreshInterval time.Duration
+	ConnectionRefreshInterval time.Duration `yaml:"connection_refresh_interval"`
+
+	// Issue reporting runs on a background worker: issues are queued, picked
+	// up in batches of up to ReportingBatchSize, each retried for
+	// ReportingMaxRetryTime and, when ReportingSpoolFile is set, spooled
+	// there while they still cannot be delivered. Spooling is off by default;
+	// point it at an absolute path on a persistent volume to enable it.
+	// Each issue type is limited to ReportingRateLimit reports a minute and
+	// identical reports within ReportingDedupWindow are dropped.
+	ReportingQueueSize     int           `yaml:"reporting_queue_size"`
+	ReportingBatchSize     int           `yaml:"reporting_batch_size"`
+	ReportingBatchWindow   time.Duration `yaml:"reporting_batch_window"`
+	ReportingMaxRetryTime  time.Duration `yaml:"reporting_max_retry_time"`
+	ReportingRateLimit     int           `yaml:"reporting_rate_limit"`
+	ReportingDedupWindow   time.Duration `yaml:"reporting_dedup_window"`
+	ReportingSpoolFile     string        `yaml:"reporting_spool_file"`
+	ReportingSpoolMaxBytes int           `yaml:"reporting_spool_max_bytes"`
+
+	// Hex private key of the operator's signer account. When set, reports are
+	// signed (EIP-191 over the JSON body) so the backend can check they come
+	// from SignerAccountAddress.
+	ReportingSigningKey string `yaml:"reporting_signing_key"`
+
+	// Per-submission ledger kept for LedgerRetention. Off unless LedgerFile
+	// is set; use an absolute path on a persistent volume.
+	LedgerFile      string        `yaml:"ledger_file"`
+	LedgerRetention time.Duration `yaml:"ledger_retention"`
+	LedgerQueueSize int           `yaml:"ledger_queue_size"`
+
+	// Per-epoch submission metrics are kept for the EpochMetricsWindow newest
+	// epochs
+	EpochMetricsWindow int `yaml:"epoch_metrics_window"`
+
+	// An epoch more than EpochMaxAdvance above the latest one is tracked once
+	// EpochJumpConfirmations submissions have arrived for it. When nothing is
+	// submitted near the latest epoch for EpochAnchorTimeout, the window moves
+	// back to the epochs still receiving submissions; zero disables that.
+	EpochMaxAdvance        int           `yaml:"epoch_max_advance"`
+	EpochJumpConfirmations int           `yaml:"epoch_jump_confirmations"`
+	EpochAnchorTimeout     time.Duration `yaml:"epoch_anchor_timeout"`
+
+	// An epoch is closed and summarised once it has had no submission for
+	// EpochQuietPeriod or, when set, EpochSubmissionDeadline after its first
+	// submission
+	EpochQuietPeriod        time.Duration `yaml:"epoch_quiet_period"`
+	EpochSubmissionDeadline time.Duration `yaml:"epoch_submission_deadline"`
+
+	// Post each epoch summary to the /epochSummary endpoint of the reporting
+	// service. Off by default, as not every reporting backend provides it.
+	EpochSummaryReporting bool `yaml:"epoch_summary_reporting"`
+
+	// Liveness heartbeats posted to the reporting service
+	HeartbeatEnabled  bool          `yaml:"heartbeat_enabled"`
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+
+	// Webhooks deliver issues to the operator's own tools. They can only be
+	// configured in the YAML file.
+	Webhooks []WebhookSink `yaml:"webhooks"`
+}
+
+// LoadConfig loads the settings from the YAML file at path (if any) and the
+// environment and makes them current, exiting with every problem found if
+// they are invalid
+func LoadConfig(path string) {
+	settings, err := Load(path)
+	if err != nil {
+		// The logger is not configured yet, so print the problems one per line
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	Set(settings)
 }
 
-func LoadConfig() {
-	config := Settings{}
+// Load builds settings from the defaults, overlaid by the YAML file at path
+// when path is not empty, overlaid in turn by environment variables. All
+// problems are reported together.
+func Load(path string) (*Settings, error) {
+	config := DefaultSettings()
 
-	// Required fields
-	if port := os.Getenv("LOCAL_COLLECTOR_PORT"); port != "" {
-		config.PortNumber = port
-	} else {
-		config.PortNumber = "50051" // Default value
+	if path != "" {
+		if err := loadFile(path, &config); err != nil {
+			return nil, err
+		}
 	}
 
-	if contract := os.Getenv("DATA_MARKET_CONTRACT"); contract == "" {
-		log.Fatal("DATA_MARKET_CONTRACT environment variable is required")
-	} else {
-		config.DataMarketAddress = contract
+	env := &envReader{}
+	env.apply(&config)
+
+	problems := append(env.errs, config.Validate()...)
+	if len(problems) > 0 {
+		return nil, errors.Join(problems...)
 	}
-	if value := os.Getenv("DATA_MARKET_IN_REQUEST"); value == "true" {
-		config.DataMarketInRequest = true
-	} else {
-		config.DataMarketInRequest = false
+	return &config, nil
+}
+
+// DefaultSettings returns the settings used when neither the config file nor
+// the environment sets a value
+func DefaultSettings() Settings {
+	return Settings{
+		PortNumber:                "50051",
+		TrustedRelayersListUrl:    "https://raw.githubusercontent.com/PowerLoom/snapshotter-lite-local-collector/feat/trusted-relayers/relayers.json",
+		SequencersListUrl:         "https://raw.githubusercontent.com/PowerLoom/snapshotter-lite-local-collector/feat/trusted-relayers/sequencers.json",
+		ReportingTimeout:          10 * time.Second,
+		MaxStreamPoolSize:         100,
+		StreamHealthCheckTimeout:  5000 * time.Millisecond,
+		StreamWriteTimeout:        5000 * time.Millisecond,
+		MaxWriteRetries:           5,
+		MaxConcurrentWrites:       100,
+		MaxStreamQueueSize:        1000,
+		WorkerPoolSize:            250,
+		SubmissionTimeout:         30000 * time.Millisecond,
+		SubmissionQueueSize:       10000,
+		BatchWindow:               50 * time.Millisecond,
+		BatchMaxCount:             100,
+		BatchMaxBytes:             1 << 20,
+		StreamStrategy:            "pooled",
+		EphemeralResponseTimeout:  2000 * time.Millisecond,
+		LaneWeightCurrent:         8,
+		LaneWeightLate:            3,
+		LaneWeightSimulation:      1,
+		LaneQueueSize:             1000,
+		ValidationAction:          "flag",
+		MaxSubmissionBytes:        8192,
+		ChainHeadProvider:         "none",
+		ChainPollInterval:         2 * time.Second,
+		DeadlineAction:            "flag",
+		DeadlineUrgentBlocks:      2,
+		MetricsHost:               "127.0.0.1",
+		MetricsPort:               "9090",
+		TracingExporter:           "otlp",
+		TracingEndpoint:           "localhost:4317",
+		TracingInsecure:           true,
+		TracingFile:               "traces.json",
+		TracingSamplePercent:      100,
+		AdminEnabled:              true,
+		AdminHost:                 "127.0.0.1",
+		AdminPort:                 "9091",
+		LogLevel:                  "info",
+		LogFormat:                 "text",
+		LogFileMaxSizeMB:          100,
+		LogFileMaxAgeDays:         7,
+		LogFileMaxBackups:         5,
+		LogSuccessSampleEvery:     1,
+		LogLevelOverridesFile:     "log_levels.conf",
+		LogLevelOverrideTTL:       600 * time.Second,
+		ConnectionRefreshInterval: 300 * time.Second,
+		ReportingQueueSize:        1000,
+		ReportingBatchSize:        1,
+		ReportingBatchWindow:      time.Second,
+		ReportingMaxRetryTime:     60 * time.Second,
+		ReportingRateLimit:        10,
+		ReportingDedupWindow:      300 * time.Second,
+		ReportingSpoolMaxBytes:    10 << 20,
+		EpochMetricsWindow:        4,
+		EpochMaxAdvance:           1000,
+		EpochJumpConfirmations:    2,
+		EpochAnchorTimeout:        5 * time.Minute,
+		EpochQuietPeriod:          30 * time.Second,
+		HeartbeatInterval:         60 * time.Second,
+		LedgerRetention:           7 * 24 * time.Hour,
+		LedgerQueueSize:           10000,
 	}
+}
+
+// loadFile overlays the YAML file at path onto config. Unknown keys are
+// rejected so typos do not silently fall back to defaults.
+func loadFile(path string, config *Settings) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// apply overlays the environment variables that are set onto config
+func (env *envReader) apply(config *Settings) {
+	config.PortNumber = getEnvWithDefault("LOCAL_COLLECTOR_PORT", config.PortNumber)
+	config.DataMarketAddress = getEnvWithDefault("DATA_MARKET_CONTRACT", config.DataMarketAddress)
+	config.DataMarketInRequest = env.bool("DATA_MARKET_IN_REQUEST", config.DataMarketInRequest)
+
 	// Optional fields with defaults
-	config.PowerloomReportingUrl = os.Getenv("POWERLOOM_REPORTING_URL")
-	config.SignerAccountAddress = os.Getenv("SIGNER_ACCOUNT_ADDRESS")
-	config.TrustedRelayersListUrl = getEnvWithDefault("TRUSTED_RELAYERS_LIST_URL", "https://raw.githubusercontent.com/PowerLoom/snapshotter-lite-local-collector/feat/trusted-relayers/relayers.json")
+	config.PowerloomReportingUrl = getEnvWithDefault("POWERLOOM_REPORTING_URL", config.PowerloomReportingUrl)
+	config.ReportingTimeout = env.duration("REPORTING_TIMEOUT_MS", time.Millisecond, config.ReportingTimeout)
+	config.SignerAccountAddress = getEnvWithDefault("SIGNER_ACCOUNT_ADDRESS", config.SignerAccountAddress)
+	config.TrustedRelayersListUrl = getEnvWithDefault("TRUSTED_RELAYERS_LIST_URL", config.TrustedRelayersListUrl)
+	config.SequencersListUrl = getEnvWithDefault("SEQUENCERS_LIST_URL", config.SequencersListUrl)
 
 	// Load private key from file or env
-	config.RelayerPrivateKey = loadPrivateKey()
+	if key := loadPrivateKey(); key != "" {
+		config.RelayerPrivateKey = key
+	}
 
 	// Numeric values with defaults
-	config.MaxStreamPoolSize = getEnvAsInt("MAX_STREAM_POOL_SIZE", 100)
-	config.StreamHealthCheckTimeout = time.Duration(getEnvAsInt("STREAM_HEALTH_CHECK_TIMEOUT_MS", 5000)) * time.Millisecond
-	config.StreamWriteTimeout = time.Duration(getEnvAsInt("STREAM_WRITE_TIMEOUT_MS", 5000)) * time.Millisecond
-	config.MaxWriteRetries = getEnvAsInt("MAX_WRITE_RETRIES", 5)
-	config.MaxConcurrentWrites = getEnvAsInt("MAX_CONCURRENT_WRITES", 100)
-	config.MaxStreamQueueSize = getEnvAsInt("MAX_STREAM_QUEUE_SIZE", 1000)
-	config.WorkerPoolSize = getEnvAsInt("WORKER_POOL_SIZE", 250)
+	config.MaxStreamPoolSize = env.int("MAX_STREAM_POOL_SIZE", config.MaxStreamPoolSize)
+	config.StreamHealthCheckTimeout = env.duration("STREAM_HEALTH_CHECK_TIMEOUT_MS", time.Millisecond, config.StreamHealthCheckTimeout)
+	config.StreamWriteTimeout = env.duration("STREAM_WRITE_TIMEOUT_MS", time.Millisecond, config.StreamWriteTimeout)
+	config.MaxWriteRetries = env.int("MAX_WRITE_RETRIES", config.MaxWriteRetries)
+	config.MaxConcurrentWrites = env.int("MAX_CONCURRENT_WRITES", config.MaxConcurrentWrites)
+	config.MaxStreamQueueSize = env.int("MAX_STREAM_QUEUE_SIZE", config.MaxStreamQueueSize)
+	config.WorkerPoolSize = env.int("WORKER_POOL_SIZE", config.WorkerPoolSize)
+	config.SubmissionTimeout = env.duration("SUBMISSION_TIMEOUT_MS", time.Millisecond, config.SubmissionTimeout)
+	config.AsyncSubmissionMode = env.bool("ASYNC_SUBMISSION_MODE", config.AsyncSubmissionMode)
+	config.SubmissionQueueSize = env.int("SUBMISSION_QUEUE_SIZE", config.SubmissionQueueSize)
+	config.BatchSubmissions = env.bool("BATCH_SUBMISSIONS", config.BatchSubmissions)
+	config.BatchWindow = env.duration("BATCH_WINDOW_MS", time.Millisecond, config.BatchWindow)
+	config.BatchMaxCount = env.int("BATCH_MAX_COUNT", config.BatchMaxCount)
+	config.BatchMaxBytes = env.int("BATCH_MAX_BYTES", config.BatchMaxBytes)
+	config.StreamStrategy = getEnvWithDefault("STREAM_STRATEGY", config.StreamStrategy)
+	config.EphemeralReadResponse = env.bool("EPHEMERAL_READ_RESPONSE", config.EphemeralReadResponse)
+	config.EphemeralResponseTimeout = env.duration("EPHEMERAL_RESPONSE_TIMEOUT_MS", time.Millisecond, config.EphemeralResponseTimeout)
+	config.LaneWeightCurrent = env.int("LANE_WEIGHT_CURRENT", config.LaneWeightCurrent)
+	config.LaneWeightLate = env.int("LANE_WEIGHT_LATE", config.LaneWeightLate)
+	config.LaneWeightSimulation = env.int("LANE_WEIGHT_SIMULATION", config.LaneWeightSimulation)
+	config.LaneQueueSize = env.int("LANE_QUEUE_SIZE", config.LaneQueueSize)
+	config.ValidationAction = getEnvWithDefault("VALIDATION_ACTION", config.ValidationAction)
+	config.MaxSubmissionBytes = env.int("MAX_SUBMISSION_BYTES", config.MaxSubmissionBytes)
+	config.SimulationMaxInFlight = env.int("SIMULATION_MAX_IN_FLIGHT", config.SimulationMaxInFlight)
+	config.ChainHeadProvider = getEnvWithDefault("CHAIN_HEAD_PROVIDER", config.ChainHeadProvider)
+	config.ChainRPCURL = getEnvWithDefault("CHAIN_RPC_URL", config.ChainRPCURL)
+	config.ChainPollInterval = env.duration("CHAIN_POLL_INTERVAL_MS", time.Millisecond, config.ChainPollInterval)
+	config.ChainStaticBlock = env.int("CHAIN_STATIC_BLOCK", config.ChainStaticBlock)
+	config.DeadlineAction = getEnvWithDefault("DEADLINE_ACTION", config.DeadlineAction)
+	config.DeadlineUrgentBlocks = env.int("DEADLINE_URGENT_BLOCKS", config.DeadlineUrgentBlocks)
+	config.MetricsEnabled = env.bool("METRICS_ENABLED", config.MetricsEnabled)
+	config.MetricsHost = getEnvWithDefault("METRICS_HOST", config.MetricsHost)
+	config.MetricsPort = getEnvWithDefault("METRICS_PORT", config.MetricsPort)
+	config.TracingEnabled = env.bool("TRACING_ENABLED", config.TracingEnabled)
+	config.TracingExporter = getEnvWithDefault("TRACING_EXPORTER", config.TracingExporter)
+	config.TracingEndpoint = getEnvWithDefault("OTEL_EXPORTER_OTLP_ENDPOINT", config.TracingEndpoint)
+	config.TracingInsecure = env.bool("OTEL_EXPORTER_OTLP_INSECURE", config.TracingInsecure)
+	config.TracingFile = getEnvWithDefault("TRACING_FILE", config.TracingFile)
+	config.TracingSamplePercent = env.int("TRACING_SAMPLE_PERCENT", config.TracingSamplePercent)
+	config.AdminEnabled = env.bool("ADMIN_ENABLED", config.AdminEnabled)
+	config.AdminHost = getEnvWithDefault("ADMIN_HOST", config.AdminHost)
+	config.AdminPort = getEnvWithDefault("ADMIN_PORT", config.AdminPort)
+	config.AdminToken = getEnvWithDefault("ADMIN_TOKEN", config.AdminToken)
+
+	// Logging
+	config.LogLevel = getEnvWithDefault("LOG_LEVEL", config.LogLevel)
+	config.LogFormat = getEnvWithDefault("LOG_FORMAT", config.LogFormat)
+	config.LogFile = getEnvWithDefault("LOG_FILE", config.LogFile)
+	config.LogFileMaxSizeMB = env.int("LOG_FILE_MAX_SIZE_MB", config.LogFileMaxSizeMB)
+	config.LogFileMaxAgeDays = env.int("LOG_FILE_MAX_AGE_DAYS", config.LogFileMaxAgeDays)
+	config.LogFileMaxBackups = env.int("LOG_FILE_MAX_BACKUPS", config.LogFileMaxBackups)
+	config.LogSuccessSampleEvery = env.int("LOG_SUCCESS_SAMPLE_EVERY", config.LogSuccessSampleEvery)
+	config.LogLevelOverridesFile = getEnvWithDefault("LOG_LEVEL_OVERRIDES_FILE", config.LogLevelOverridesFile)
+	config.LogLevelOverrideTTL = env.duration("LOG_LEVEL_OVERRIDE_TTL_SEC", time.Second, config.LogLevelOverrideTTL)
 
-	// Add log level setting (default "info")
-	config.LogLevel = getEnvWithDefault("LOG_LEVEL", "info")
+	// Connection refresh interval
+	config.ConnectionRefreshInterval = env.duration("CONNECTION_REFRESH_INTERVAL_SEC", time.Second, config.ConnectionRefreshInterval)
 
-	// Add connection refresh interval setting (default 5 minutes)
-	config.ConnectionRefreshInterval = time.Duration(getEnvAsInt("CONNECTION_REFRESH_INTERVAL_SEC", 300)) * time.Second
+	// Issue reporting
+	config.ReportingQueueSize = env.int("REPORTING_QUEUE_SIZE", config.ReportingQueueSize)
+	config.ReportingBatchSize = env.int("REPORTING_BATCH_SIZE", config.ReportingBatchSize)
+	config.ReportingBatchWindow = env.duration("REPORTING_BATCH_WINDOW_MS", time.Millisecond, config.ReportingBatchWindow)
+	config.ReportingMaxRetryTime = env.duration("REPORTING_MAX_RETRY_TIME_SEC", time.Second, config.ReportingMaxRetryTime)
+	config.ReportingRateLimit = env.int("REPORTING_RATE_LIMIT", config.ReportingRateLimit)
+	config.ReportingDedupWindow = env.duration("REPORTING_DEDUP_WINDOW_SEC", time.Second, config.ReportingDedupWindow)
+	config.ReportingSpoolFile = getEnvWithDefault("REPORTING_SPOOL_FILE", config.ReportingSpoolFile)
+	config.ReportingSpoolMaxBytes = env.int("REPORTING_SPOOL_MAX_BYTES", config.ReportingSpoolMaxBytes)
+	config.ReportingSigningKey = getEnvWithDefault("REPORTING_SIGNING_KEY", config.ReportingSigningKey)
+	config.LedgerFile = getEnvWithDefault("LEDGER_FILE", config.LedgerFile)
+	config.LedgerRetention = env.duration("LEDGER_RETENTION_HOURS", time.Hour, config.LedgerRetention)
+	config.LedgerQueueSize = env.int("LEDGER_QUEUE_SIZE", config.LedgerQueueSize)
+	config.EpochMetricsWindow = env.int("EPOCH_METRICS_WINDOW", config.EpochMetricsWindow)
+	config.EpochMaxAdvance = env.int("EPOCH_MAX_ADVANCE", config.EpochMaxAdvance)
+	config.EpochJumpConfirmations = env.int("EPOCH_JUMP_CONFIRMATIONS", config.EpochJumpConfirmations)
+	config.EpochAnchorTimeout = env.duration("EPOCH_ANCHOR_TIMEOUT_SEC", time.Second, config.EpochAnchorTimeout)
+	config.EpochQuietPeriod = env.duration("EPOCH_QUIET_PERIOD_SEC", time.Second, config.EpochQuietPeriod)
+	config.EpochSubmissionDeadline = env.duration("EPOCH_SUBMISSION_DEADLINE_SEC", time.Second, config.EpochSubmissionDeadline)
+	config.EpochSummaryReporting = env.bool("EPOCH_SUMMARY_REPORTING", config.EpochSummaryReporting)
+	config.HeartbeatEnabled = env.bool("HEARTBEAT_ENABLED", config.HeartbeatEnabled)
+	config.HeartbeatInterval = env.duration("HEARTBEAT_INTERVAL_SEC", time.Second, config.HeartbeatInterval)
+}
 
-	SettingsObj = &config
+// Redacted returns a copy of the settings safe to display, with secrets masked
+func (s Settings) Redacted() Settings {
+	if s.RelayerPrivateKey != "" {
+		s.RelayerPrivateKey = redactedValue
+	}
+	if s.ReportingSigningKey != "" {
+		s.ReportingSigningKey = redactedValue
+	}
+	if s.AdminToken != "" {
+		s.AdminToken = redactedValue
+	}
+	// RPC endpoints commonly carry an API key in the URL
+	if s.ChainRPCURL != "" {
+		s.ChainRPCURL = redactedValue
+	}
+	// Webhook URLs and headers often embed tokens
+	if s.Webhooks != nil {
+		webhooks := make([]WebhookSink, len(s.Webhooks))
+		for i, sink := range s.Webhooks {
+			sink.URL = redactedValue
+			if sink.Headers != nil {
+				headers := make(map[string]string, len(sink.Headers))
+				for name := range sink.Headers {
+					headers[name] = redactedValue
+				}
+				sink.Headers = headers
+			}
+			webhooks[i] = sink
+		}
+		s.Webhooks = webhooks
+	}
+	return s
 }
 
+const redactedValue = "[REDACTED]"
+
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -89,14 +464,50 @@ func getEnvWithDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
-		}
-		log.Warnf("Invalid value for %s, using default: %d", key, defaultValue)
+// envReader parses typed environment variables, collecting malformed values
+// instead of silently falling back to defaults
+type envReader struct {
+	errs []error
+}
+
+func (env *envReader) int(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
 	}
-	return defaultValue
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		env.errs = append(env.errs, fmt.Errorf("%s: %q is not an integer", key, value))
+		return defaultValue
+	}
+	return intVal
+}
+
+func (env *envReader) bool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolVal, err := strconv.ParseBool(value)
+	if err != nil {
+		env.errs = append(env.errs, fmt.Errorf("%s: %q is not a boolean", key, value))
+		return defaultValue
+	}
+	return boolVal
+}
+
+// duration reads an integer count of unit, such as milliseconds for *_MS keys
+func (env *envReader) duration(key string, unit time.Duration, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		env.errs = append(env.errs, fmt.Errorf("%s: %q is not an integer", key, value))
+		return defaultValue
+	}
+	return time.Duration(intVal) * unit
 }
 
 func loadPrivateKey() string {
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMergesFileAndEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(
+		"data_market_address: \"0x21cb57C1f2352ad215a463DD867b838749CD3b8f\"\n"+
+			"max_stream_pool_size: 20\n"+
+			"stream_write_timeout: 3s\n"), 0o600))
+	t.Setenv("MAX_STREAM_POOL_SIZE", "30")
+
+	settings, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, settings.MaxStreamPoolSize)
+	assert.Equal(t, 3*time.Second, settings.StreamWriteTimeout)
+	assert.Equal(t, 100, settings.MaxConcurrentWrites)
+	assert.Empty(t, settings.LedgerFile, "ledger is opt-in")
+	assert.Empty(t, settings.ReportingSpoolFile, "spooling is opt-in")
+	assert.False(t, settings.MetricsEnabled, "metrics are opt-in")
+	assert.Equal(t, "127.0.0.1", settings.MetricsHost)
+	assert.Equal(t, "flag", settings.ValidationAction, "malformed submissions are forwarded by default")
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("DATA_MARKET_CONTRACT", "0x1234")
+	t.Setenv("MAX_CONCURRENT_WRITES", "many")
+	t.Setenv("WORKER_POOL_SIZE", "-1")
+	t.Setenv("SUBMISSION_TIMEOUT_MS", "0")
+
+	_, err := Load("")
+	assert.Error(t, err)
+	for _, problem := range []string{"data_market_address", "MAX_CONCURRENT_WRITES", "worker_pool_size", "submission_timeout"} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestAdminTokenRequiredOffLoopback(t *testing.T) {
+	t.Setenv("DATA_MARKET_CONTRACT", "0x21cb57C1f2352ad215a463DD867b838749CD3b8f")
+
+	settings, err := Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", settings.AdminHost)
+
+	t.Setenv("ADMIN_HOST", "0.0.0.0")
+	_, err = Load("")
+	assert.ErrorContains(t, err, "admin_token")
+
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	_, err = Load("")
+	assert.NoError(t, err)
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("max_stream_pool_sise: 20\n"), 0o600))
+
+	_, err := Load(path)
+	assert.Error(t, err)
+}
+
+func TestRedactedMasksPrivateKey(t *testing.T) {
+	settings := Settings{RelayerPrivateKey: "secret", ReportingSigningKey: "signing-secret", AdminToken: "token"}
+	assert.Equal(t, redactedValue, settings.Redacted().RelayerPrivateKey)
+	assert.Equal(t, redactedValue, settings.Redacted().ReportingSigningKey)
+	assert.Equal(t, redactedValue, settings.Redacted().AdminToken)
+	assert.Equal(t, "secret", settings.RelayerPrivateKey)
+}
+
+func TestDiffAndKeep(t *testing.T) {
+	old := DefaultSettings()
+	old.RelayerPrivateKey = "old-secret"
+	next := old
+	next.MaxConcurrentWrites = 50
+	next.PortNumber = "6000"
+	next.RelayerPrivateKey = "new-secret"
+
+	changes := Diff(&old, &next)
+	assert.ElementsMatch(t, []Change{
+		{Field: "max_concurrent_writes", Old: "100", New: "50"},
+		{Field: "port_number", Old: "50051", New: "6000"},
+		{Field: "relayer_private_key", Old: redactedValue, New: redactedValue},
+	}, changes)
+
+	next.Keep(&old, "port_number")
+	assert.Equal(t, "50051", next.PortNumber)
+	assert.Equal(t, 50, next.MaxConcurrentWrites)
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	settings := DefaultSettings()
+	settings.DataMarketAddress = "0x21cb57C1f2352ad215a463DD867b838749CD3b8f"
+	settings.Webhooks = []WebhookSink{
+		{Name: "slack", URL: "https://hooks.example.com/secret", Template: `{"text": {{ .IssueType | json }}}`},
+		{Name: "slack", URL: "ftp://example.com", MinSeverity: "loud", Template: "{{ .IssueType"},
+	}
+
+	var problems []string
+	for _, err := range settings.Validate() {
+		problems = append(problems, err.Error())
+	}
+	assert.Len(t, problems, 4)
+	for _, field := range []string{"webhooks[1].name", "webhooks[1].url", "webhooks[1].min_severity", "webhooks[1].template"} {
+		assert.Contains(t, strings.Join(problems, "\n"), field)
+	}
+
+	assert.Equal(t, redactedValue, settings.Redacted().Webhooks[0].URL)
+	assert.Equal(t, "https://hooks.example.com/secret", settings.Webhooks[0].URL)
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	addressPattern    = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	privateKeyPattern = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+)
+
+// Validate checks the settings and returns every problem found
+func (s *Settings) Validate() []error {
+	v := &validator{}
+
+	if s.DataMarketAddress == "" {
+		v.add("data_market_address", "is required (DATA_MARKET_CONTRACT)")
+	} else if !addressPattern.MatchString(s.DataMarketAddress) {
+		v.add("data_market_address", fmt.Sprintf("%q is not a 0x-prefixed 20-byte hex address", s.DataMarketAddress))
+	}
+	if s.SignerAccountAddress != "" && !addressPattern.MatchString(s.SignerAccountAddress) {
+		v.add("signer_account_address", fmt.Sprintf("%q is not a 0x-prefixed 20-byte hex address", s.SignerAccountAddress))
+	}
+
+	if s.ReportingSigningKey != "" {
+		if !privateKeyPattern.MatchString(s.ReportingSigningKey) {
+			v.add("reporting_signing_key", "is not a 32-byte hex private key")
+		}
+		if s.SignerAccountAddress == "" {
+			v.add("reporting_signing_key", "requires signer_account_address, the account it signs for")
+		}
+	}
+
+	v.port("port_number", s.PortNumber)
+	v.port("metrics_port", s.MetricsPort)
+	v.port("admin_port", s.AdminPort)
+	if s.AdminEnabled && s.AdminToken == "" && !isLoopback(s.AdminHost) {
+		v.add("admin_token", fmt.Sprintf("is required when the admin API listens on %q, which is not a loopback address", s.AdminHost))
+	}
+
+	v.httpURL("trusted_relayers_list_url", s.TrustedRelayersListUrl, true)
+	v.httpURL("sequencers_list_url", s.SequencersListUrl, true)
+	v.httpURL("powerloom_reporting_url", s.PowerloomReportingUrl, false)
+
+	v.positive("max_stream_pool_size", s.MaxStreamPoolSize)
+	v.positive("max_concurrent_writes", s.MaxConcurrentWrites)
+	v.positive("max_stream_queue_size", s.MaxStreamQueueSize)
+	v.positive("worker_pool_size", s.WorkerPoolSize)
+	v.positive("submission_queue_size", s.SubmissionQueueSize)
+	v.positive("batch_max_count", s.BatchMaxCount)
+	v.positive("batch_max_bytes", s.BatchMaxBytes)
+	v.positive("lane_queue_size", s.LaneQueueSize)
+	v.nonNegative("max_submission_bytes", s.MaxSubmissionBytes)
+	v.positive("lane_weight_current", s.LaneWeightCurrent)
+	v.positive("lane_weight_late", s.LaneWeightLate)
+	v.positive("lane_weight_simulation", s.LaneWeightSimulation)
+	v.positive("log_success_sample_every", s.LogSuccessSampleEvery)
+	v.nonNegative("max_write_retries", s.MaxWriteRetries)
+	v.nonNegative("simulation_max_in_flight", s.SimulationMaxInFlight)
+	v.nonNegative("log_file_max_size_mb", s.LogFileMaxSizeMB)
+	v.nonNegative("log_file_max_age_days", s.LogFileMaxAgeDays)
+	v.nonNegative("log_file_max_backups", s.LogFileMaxBackups)
+	v.positive("reporting_queue_size", s.ReportingQueueSize)
+	v.positive("reporting_batch_size", s.ReportingBatchSize)
+	v.positive("reporting_rate_limit", s.ReportingRateLimit)
+	v.nonNegative("reporting_spool_max_bytes", s.ReportingSpoolMaxBytes)
+	if s.TracingSamplePercent < 0 || s.TracingSamplePercent > 100 {
+		v.add("tracing_sample_percent", fmt.Sprintf("must be between 0 and 100, got %d", s.TracingSamplePercent))
+	}
+
+	v.timeout("stream_health_check_timeout", s.StreamHealthCheckTimeout)
+	v.timeout("stream_write_timeout", s.StreamWriteTimeout)
+	v.timeout("submission_timeout", s.SubmissionTimeout)
+	v.timeout("batch_window", s.BatchWindow)
+	v.timeout("ephemeral_response_timeout", s.EphemeralResponseTimeout)
+	v.timeout("log_level_override_ttl", s.LogLevelOverrideTTL)
+	v.timeout("connection_refresh_interval", s.ConnectionRefreshInterval)
+	v.timeout("reporting_timeout", s.ReportingTimeout)
+	v.timeout("reporting_batch_window", s.ReportingBatchWindow)
+	v.timeout("reporting_max_retry_time", s.ReportingMaxRetryTime)
+	v.timeout("heartbeat_interval", s.HeartbeatInterval)
+	v.positive("epoch_metrics_window", s.EpochMetricsWindow)
+	v.positive("epoch_max_advance", s.EpochMaxAdvance)
+	v.positive("epoch_jump_confirmations", s.EpochJumpConfirmations)
+	if s.EpochAnchorTimeout < 0 {
+		v.add("epoch_anchor_timeout", fmt.Sprintf("must not be negative, got %v", s.EpochAnchorTimeout))
+	}
+	v.timeout("epoch_quiet_period", s.EpochQuietPeriod)
+	if s.EpochSubmissionDeadline < 0 {
+		v.add("epoch_submission_deadline", fmt.Sprintf("must not be negative, got %v", s.EpochSubmissionDeadline))
+	}
+	v.timeout("ledger_retention", s.LedgerRetention)
+	v.positive("ledger_queue_size", s.LedgerQueueSize)
+	if s.HeartbeatEnabled && s.PowerloomReportingUrl == "" {
+		v.add("heartbeat_enabled", "requires powerloom_reporting_url")
+	}
+	if s.ReportingDedupWindow < 0 {
+		v.add("reporting_dedup_window", fmt.Sprintf("must not be negative, got %v", s.ReportingDedupWindow))
+	}
+
+	v.oneOf("stream_strategy", s.StreamStrategy, "pooled", "ephemeral")
+	v.oneOf("tracing_exporter", s.TracingExporter, "otlp", "file")
+	v.oneOf("log_format", s.LogFormat, "text", "json")
+	v.oneOf("chain_head_provider", s.ChainHeadProvider, "none", "rpc", "static")
+	v.oneOf("deadline_action", s.DeadlineAction, "flag", "reject")
+	v.oneOf("validation_action", s.ValidationAction, "off", "flag", "reject")
+	v.httpURL("chain_rpc_url", s.ChainRPCURL, s.ChainHeadProvider == "rpc")
+	v.timeout("chain_poll_interval", s.ChainPollInterval)
+	v.nonNegative("chain_static_block", s.ChainStaticBlock)
+	v.nonNegative("deadline_urgent_blocks", s.DeadlineUrgentBlocks)
+	if _, err := log.ParseLevel(s.LogLevel); err != nil {
+		v.add("log_level", err.Error())
+	}
+
+	names := make(map[string]bool, len(s.Webhooks))
+	for i, sink := range s.Webhooks {
+		field := fmt.Sprintf("webhooks[%d]", i)
+		if sink.Name == "" {
+			v.add(field+".name", "is required")
+		} else if names[sink.Name] {
+			v.add(field+".name", fmt.Sprintf("%q is used by another webhook", sink.Name))
+		}
+		names[sink.Name] = true
+		v.httpURL(field+".url", sink.URL, true)
+		if sink.MinSeverity != "" {
+			v.oneOf(field+".min_severity", sink.MinSeverity, Severities...)
+		}
+		if _, err := sink.ParseTemplate(); err != nil {
+			v.add(field+".template", err.Error())
+		}
+	}
+
+	return v.errs
+}
+
+// isLoopback reports whether host only accepts local connections. An empty
+// host listens on every interface.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validator accumulates problems as "field: message" errors
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(field, message string) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", field, message))
+}
+
+func (v *validator) positive(field string, value int) {
+	if value <= 0 {
+		v.add(field, fmt.Sprintf("must be positive, got %d", value))
+	}
+}
+
+func (v *validator) nonNegative(field string, value int) {
+	if value < 0 {
+		v.add(field, fmt.Sprintf("must not be negative, got %d", value))
+	}
+}
+
+func (v *validator) timeout(field string, value time.Duration) {
+	if value <= 0 {
+		v.add(field, fmt.Sprintf("must be greater than zero, got %v", value))
+	}
+}
+
+func (v *validator) port(field, value string) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.add(field, fmt.Sprintf("%q is not a valid TCP port", value))
+	}
+}
+
+func (v *validator) httpURL(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, fmt.Sprintf("%q is not an http(s) URL", value))
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(field, fmt.Sprintf("%q is not one of %v", value, allowed))
+}
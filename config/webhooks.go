@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+)
+
+// Severities a webhook can filter on, least severe first
+var Severities = []string{"info", "warning", "critical"}
+
+// WebhookSink posts issues to an HTTP endpoint, rendering the body with a Go
+// text/template. An empty template sends the issue as JSON.
+//
+//	webhooks:
+//	  - name: slack
+//	    url: https://hooks.slack.com/services/...
+//	    min_severity: critical
+//	    template: '{"text": {{ printf "%s: %s" .IssueType .Extra | json }}}'
+type WebhookSink struct {
+	Name        string            `yaml:"name"`
+	URL         string            `yaml:"url"`
+	Template    string            `yaml:"template"`
+	ContentType string            `yaml:"content_type"`
+	Headers     map[string]string `yaml:"headers"`
+	IssueTypes  []string          `yaml:"issue_types"`  // Empty sends every type
+	MinSeverity string            `yaml:"min_severity"` // info, warning or critical
+}
+
+// webhookTemplateFuncs are available to webhook templates
+var webhookTemplateFuncs = template.FuncMap{
+	// json encodes a value for embedding in a JSON body, quotes included
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseTemplate parses the sink's body template, or returns nil when the
+// issue should be sent as plain JSON
+func (w WebhookSink) ParseTemplate() (*template.Template, error) {
+	if strings.TrimSpace(w.Template) == "" {
+		return nil, nil
+	}
+	return template.New(w.Name).Funcs(webhookTemplateFuncs).Option("missingkey=error").Parse(w.Template)
+}
@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// EthereumSigner signs messages with an Ethereum account key
+type EthereumSigner struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+// NewEthereumSigner parses a hex encoded secp256k1 private key, with or
+// without a 0x prefix
+func NewEthereumSigner(hexKey string) (*EthereumSigner, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil || len(keyBytes) != 32 {
+		return nil, fmt.Errorf("signing key must be 32 hex encoded bytes")
+	}
+	key := secp256k1.PrivKeyFromBytes(keyBytes)
+	pub := key.PubKey().SerializeUncompressed()
+	return &EthereumSigner{
+		key:     key,
+		address: "0x" + hex.EncodeToString(keccak256(pub[1:])[12:]),
+	}, nil
+}
+
+// Address returns the lower-case hex address of the signing account
+func (s *EthereumSigner) Address() string {
+	return s.address
+}
+
+// SignPersonalMessage signs message as EIP-191 personal_sign does and
+// returns the 65-byte r || s || v signature, with v of 27 or 28
+func (s *EthereumSigner) SignPersonalMessage(message []byte) []byte {
+	compact := ecdsa.SignCompact(s.key, PersonalMessageHash(message), false)
+	// SignCompact puts the recovery byte first; Ethereum expects it last
+	return append(compact[1:], compact[0])
+}
+
+// PersonalMessageHash is the EIP-191 version 0x45 hash of message
+func PersonalMessageHash(message []byte) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+	return keccak256([]byte(prefix), message)
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthereumSignerMatchesPersonalSign(t *testing.T) {
+	signer, err := NewEthereumSigner("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", signer.Address())
+
+	signature := signer.SignPersonalMessage([]byte("Some data"))
+	assert.Equal(t,
+		"b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c",
+		hex.EncodeToString(signature))
+}
+
+func TestNewEthereumSignerRejectsMalformedKeys(t *testing.T) {
+	for _, key := range []string{"", "0x1234", "not hex"} {
+		_, err := NewEthereumSigner(key)
+		assert.Error(t, err, key)
+	}
+}
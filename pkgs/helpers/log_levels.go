@@ -0,0 +1,274 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Subsystems whose log level can be raised independently
+const (
+	SubsystemGRPC       = "grpc"
+	SubsystemPool       = "pool"
+	SubsystemConnection = "connection"
+	SubsystemDiscovery  = "discovery"
+	SubsystemReporting  = "reporting"
+	SubsystemLedger     = "ledger"
+	SubsystemAdmin      = "admin"
+)
+
+// FieldSubsystem tags log lines with the subsystem that emitted them
+const FieldSubsystem = "subsystem"
+
+var subsystems = []string{SubsystemGRPC, SubsystemPool, SubsystemConnection, SubsystemDiscovery, SubsystemReporting, SubsystemLedger, SubsystemAdmin}
+
+// SubsystemLogger returns a logger whose lines are filtered by the level set
+// for subsystem
+func SubsystemLogger(subsystem string) *log.Entry {
+	return log.WithField(FieldSubsystem, subsystem)
+}
+
+// levelOverride is a temporary level for one subsystem
+type levelOverride struct {
+	level     log.Level
+	expiresAt time.Time
+	timer     *time.Timer
+	fromFile  bool // Set by ApplyLevelOverridesFile rather than SetLogLevel
+}
+
+// LevelStatus describes the effective level of one subsystem
+type LevelStatus struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// levelRegistry holds the base level and the per-subsystem overrides. The
+// global logrus level is kept at the most verbose of them so entries reach
+// the output hook, which then applies the subsystem threshold.
+type levelRegistry struct {
+	mu        sync.RWMutex
+	base      log.Level
+	overrides map[string]*levelOverride
+}
+
+var levels = &levelRegistry{
+	base:      log.InfoLevel,
+	overrides: make(map[string]*levelOverride),
+}
+
+// SetBaseLogLevel changes the level applied to everything without an override
+func SetBaseLogLevel(level log.Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+	levels.base = level
+	levels.applyLocked()
+}
+
+// SetLogLevel raises or lowers the level of subsystem until ttl elapses, after
+// which it reverts to the base level. An empty or "all" subsystem overrides
+// every subsystem.
+func SetLogLevel(subsystem string, level log.Level, ttl time.Duration) error {
+	return setLogLevel(subsystem, level, ttl, false)
+}
+
+func setLogLevel(subsystem string, level log.Level, ttl time.Duration, fromFile bool) error {
+	if ttl <= 0 {
+		return fmt.Errorf("log level override TTL must be positive, got %v", ttl)
+	}
+	targets := []string{subsystem}
+	if subsystem == "" || subsystem == "all" {
+		targets = subsystems
+	} else if !knownSubsystem(subsystem) {
+		return fmt.Errorf("unknown subsystem %q (known: %s)", subsystem, strings.Join(subsystems, ", "))
+	}
+
+	levels.mu.Lock()
+	for _, name := range targets {
+		levels.setLocked(name, level, ttl, fromFile)
+	}
+	levels.applyLocked()
+	levels.mu.Unlock()
+
+	log.Infof("🔧 Log level for %s set to '%s' for %v", strings.Join(targets, ", "), level, ttl)
+	return nil
+}
+
+// ResetLogLevels drops every override
+func ResetLogLevels() {
+	levels.reset(func(*levelOverride) bool { return true })
+}
+
+// reset drops the overrides matching drop
+func (r *levelRegistry) reset(drop func(o *levelOverride) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, o := range r.overrides {
+		if drop(o) {
+			o.timer.Stop()
+			delete(r.overrides, name)
+		}
+	}
+	r.applyLocked()
+}
+
+// LogLevels reports the effective level of the base logger and each subsystem
+func LogLevels() map[string]LevelStatus {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	result := map[string]LevelStatus{"base": {Level: levels.base.String()}}
+	for _, name := range subsystems {
+		status := LevelStatus{Level: levels.base.String()}
+		if o, ok := levels.overrides[name]; ok {
+			expiresAt := o.expiresAt
+			status = LevelStatus{Level: o.level.String(), ExpiresAt: &expiresAt}
+		}
+		result[name] = status
+	}
+	return result
+}
+
+// ApplyLevelOverridesFile reads "subsystem=level" lines from path and applies
+// each as an override for ttl, replacing the overrides the file set before.
+// Overrides set through SetLogLevel are kept unless the file names the same
+// subsystem. Blank lines and lines starting with # are ignored; a missing
+// file sets no overrides.
+func ApplyLevelOverridesFile(path string, ttl time.Duration) error {
+	fromFile := func(o *levelOverride) bool { return o.fromFile }
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		log.Debugf("No log level override file at %s", path)
+		levels.reset(fromFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open log level override file: %w", err)
+	}
+	defer f.Close()
+
+	overrides, err := parseLevelOverrides(f)
+	if err != nil {
+		return fmt.Errorf("invalid log level override file %s: %w", path, err)
+	}
+	levels.reset(fromFile)
+	for _, name := range sortedKeys(overrides) {
+		if err := setLogLevel(name, overrides[name], ttl, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseLevelOverrides(r io.Reader) (map[string]log.Level, error) {
+	overrides := make(map[string]log.Level)
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected subsystem=level", lineNo)
+		}
+		level, err := log.ParseLevel(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		overrides[strings.TrimSpace(name)] = level
+	}
+	return overrides, scanner.Err()
+}
+
+func (r *levelRegistry) setLocked(subsystem string, level log.Level, ttl time.Duration, fromFile bool) {
+	if old, ok := r.overrides[subsystem]; ok {
+		old.timer.Stop()
+	}
+	o := &levelOverride{level: level, expiresAt: time.Now().Add(ttl), fromFile: fromFile}
+	o.timer = time.AfterFunc(ttl, func() {
+		r.mu.Lock()
+		// A newer override may have replaced this one
+		if r.overrides[subsystem] != o {
+			r.mu.Unlock()
+			return
+		}
+		delete(r.overrides, subsystem)
+		r.applyLocked()
+		base := r.base
+		r.mu.Unlock()
+
+		log.Infof("🔧 Log level override for %s expired, back to '%s'", subsystem, base)
+	})
+	r.overrides[subsystem] = o
+}
+
+// applyLocked sets the global level to the most verbose level in use
+func (r *levelRegistry) applyLocked() {
+	max := r.base
+	for _, o := range r.overrides {
+		if o.level > max {
+			max = o.level
+		}
+	}
+	log.SetLevel(max)
+}
+
+// enabled reports whether an entry at level from subsystem should be written
+func (r *levelRegistry) enabled(subsystem string, level log.Level) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	threshold := r.base
+	if o, ok := r.overrides[subsystem]; ok {
+		threshold = o.level
+	}
+	return level <= threshold
+}
+
+func knownSubsystem(name string) bool {
+	for _, s := range subsystems {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]log.Level) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// outputHook writes entries that pass their subsystem's level to writer
+type outputHook struct {
+	writer    io.Writer
+	logLevels []log.Level
+}
+
+func (h *outputHook) Levels() []log.Level {
+	return h.logLevels
+}
+
+func (h *outputHook) Fire(entry *log.Entry) error {
+	subsystem, _ := entry.Data[FieldSubsystem].(string)
+	if !levels.enabled(subsystem, entry.Level) {
+		return nil
+	}
+	line, err := entry.Bytes()
+	if err != nil {
+		return err
+	}
+	_, err = h.writer.Write(line)
+	return err
+}
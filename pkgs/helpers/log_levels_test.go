@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevelOverrides(t *testing.T) {
+	overrides, err := parseLevelOverrides(strings.NewReader("# raise pool logging\npool = debug\n\nconnection=trace\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]log.Level{"pool": log.DebugLevel, "connection": log.TraceLevel}, overrides)
+
+	_, err = parseLevelOverrides(strings.NewReader("pool debug\n"))
+	assert.Error(t, err)
+
+	_, err = parseLevelOverrides(strings.NewReader("pool=loud\n"))
+	assert.Error(t, err)
+}
+
+func TestSubsystemOverrideRevertsAfterTTL(t *testing.T) {
+	SetBaseLogLevel(log.InfoLevel)
+	defer ResetLogLevels()
+
+	assert.NoError(t, SetLogLevel(SubsystemPool, log.DebugLevel, 50*time.Millisecond))
+	assert.True(t, levels.enabled(SubsystemPool, log.DebugLevel))
+	assert.False(t, levels.enabled(SubsystemGRPC, log.DebugLevel))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+
+	assert.Eventually(t, func() bool {
+		return !levels.enabled(SubsystemPool, log.DebugLevel)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, log.InfoLevel, log.GetLevel())
+
+	assert.Error(t, SetLogLevel("nonexistent", log.DebugLevel, time.Minute))
+}
+
+func TestOverridesFileKeepsAPIOverrides(t *testing.T) {
+	SetBaseLogLevel(log.InfoLevel)
+	defer ResetLogLevels()
+	path := filepath.Join(t.TempDir(), "log_levels.conf")
+
+	assert.NoError(t, SetLogLevel(SubsystemPool, log.DebugLevel, time.Minute))
+
+	// The default file usually does not exist
+	assert.NoError(t, ApplyLevelOverridesFile(path, time.Minute))
+	assert.True(t, levels.enabled(SubsystemPool, log.DebugLevel), "API override survives a reload")
+
+	assert.NoError(t, os.WriteFile(path, []byte("grpc=trace\n"), 0o600))
+	assert.NoError(t, ApplyLevelOverridesFile(path, time.Minute))
+	assert.True(t, levels.enabled(SubsystemGRPC, log.TraceLevel))
+	assert.True(t, levels.enabled(SubsystemPool, log.DebugLevel))
+
+	// Removing a line from the file drops only that override
+	assert.NoError(t, os.WriteFile(path, []byte("# nothing\n"), 0o600))
+	assert.NoError(t, ApplyLevelOverridesFile(path, time.Minute))
+	assert.False(t, levels.enabled(SubsystemGRPC, log.TraceLevel))
+	assert.True(t, levels.enabled(SubsystemPool, log.DebugLevel))
+}
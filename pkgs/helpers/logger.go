@@ -1,11 +1,33 @@
 package helpers
 
 import (
-	log "github.com/sirupsen/logrus"
-	"github.com/sirupsen/logrus/hooks/writer"
 	"io"
 	"os"
 	"proto-snapshot-server/config"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log formats selectable through LOG_FORMAT
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Field names shared by every log line that concerns a submission, so log
+// pipelines can index on them
+const (
+	FieldSubmissionID = "submission_id"
+	FieldEpochID      = "epoch_id"
+	FieldProjectID    = "project_id"
+	FieldSlotID       = "slot_id"
+	FieldStreamID     = "stream_id"
+	FieldSequencerID  = "sequencer_id"
+	FieldBatchID      = "batch_id"
 )
 
 func InitLogger() {
@@ -13,32 +35,106 @@ func InitLogger() {
 
 	log.SetReportCaller(true)
 
-	log.AddHook(&writer.Hook{ // Send logs with level higher than warning to stderr
-		Writer: os.Stderr,
-		LogLevels: []log.Level{
+	log.AddHook(&outputHook{ // Send logs with level higher than warning to stderr
+		writer: os.Stderr,
+		logLevels: []log.Level{
 			log.PanicLevel,
 			log.FatalLevel,
 			log.ErrorLevel,
 			log.WarnLevel,
 		},
 	})
-	log.AddHook(&writer.Hook{ // Send info and debug logs to stdout
-		Writer: os.Stdout,
-		LogLevels: []log.Level{
+	log.AddHook(&outputHook{ // Send info and debug logs to stdout
+		writer: os.Stdout,
+		logLevels: []log.Level{
 			log.TraceLevel,
 			log.InfoLevel,
 			log.DebugLevel,
 		},
 	})
 
-	// Set log level based on config.SettingsObj.LogLevel
-	lvl, err := log.ParseLevel(config.SettingsObj.LogLevel)
+	if config.Current().LogFile != "" {
+		log.AddHook(&outputHook{ // Mirror every level to the rotated log file
+			writer: &lumberjack.Logger{
+				Filename:   config.Current().LogFile,
+				MaxSize:    config.Current().LogFileMaxSizeMB,
+				MaxAge:     config.Current().LogFileMaxAgeDays,
+				MaxBackups: config.Current().LogFileMaxBackups,
+				Compress:   true,
+			},
+			logLevels: log.AllLevels,
+		})
+	}
+
+	switch config.Current().LogFormat {
+	case LogFormatJSON:
+		log.SetFormatter(&plainMessageFormatter{&log.JSONFormatter{
+			FieldMap: log.FieldMap{
+				log.FieldKeyMsg:  "message",
+				log.FieldKeyTime: "timestamp",
+			},
+		}})
+	default:
+		if config.Current().LogFormat != LogFormatText {
+			log.Warnf("Unknown log format '%s', defaulting to '%s'", config.Current().LogFormat, LogFormatText)
+		}
+		log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+	}
+
+	// Set log level based on config.Current().LogLevel
+	lvl, err := log.ParseLevel(config.Current().LogLevel)
 	if err != nil {
-		log.Warnf("Invalid log level '%s' from config, defaulting to 'info'", config.SettingsObj.LogLevel)
+		log.Warnf("Invalid log level '%s' from config, defaulting to 'info'", config.Current().LogLevel)
 		lvl = log.InfoLevel
 	}
-	log.SetLevel(lvl)
+	SetBaseLogLevel(lvl)
 	log.Infof("Log level set to '%s'", lvl.String())
+}
+
+// plainMessageFormatter strips the decorative emoji prefix from messages so
+// machine-readable output carries only the text
+type plainMessageFormatter struct {
+	log.Formatter
+}
+
+func (f *plainMessageFormatter) Format(entry *log.Entry) ([]byte, error) {
+	plain := *entry
+	plain.Message = strings.TrimLeftFunc(entry.Message, func(r rune) bool {
+		return unicode.Is(unicode.So, r) || unicode.Is(unicode.Mn, r) || unicode.IsSpace(r) || r == '\u200d'
+	})
+	return f.Formatter.Format(&plain)
+}
 
-	log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+// Sampler lets through one in every n calls to Sample. It is used to thin
+// out high-volume success logs.
+type Sampler struct {
+	every atomic.Uint64
+	count atomic.Uint64
+}
+
+// NewSampler returns a sampler keeping one in every n events; n <= 1 keeps all
+func NewSampler(n int) *Sampler {
+	s := &Sampler{}
+	s.SetEvery(n)
+	return s
+}
+
+// SetEvery changes the sampling rate to one in every n events
+func (s *Sampler) SetEvery(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.every.Store(uint64(n))
+}
+
+// Sample reports whether the current event should be logged
+func (s *Sampler) Sample() bool {
+	if s == nil {
+		return true
+	}
+	every := s.every.Load()
+	if every <= 1 {
+		return true
+	}
+	return s.count.Add(1)%every == 1
 }
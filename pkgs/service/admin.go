@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	log "github.com/sirupsen/logrus"
+)
+
+// SequencerStatus describes the sequencer this collector forwards to
+type SequencerStatus struct {
+	PeerID        string    `json:"peerId"`
+	Multiaddr     string    `json:"multiaddr"`
+	Connectedness string    `json:"connectedness"`
+	LastRefresh   time.Time `json:"lastRefresh"`
+	Refreshing    bool      `json:"refreshing"`
+}
+
+// CollectorStatus describes this collector's own libp2p host
+type CollectorStatus struct {
+	PeerID      string   `json:"peerId"`
+	ListenAddrs []string `json:"listenAddrs"`
+}
+
+// WritePermitStatus reports write concurrency usage
+type WritePermitStatus struct {
+	Limit    int            `json:"limit"`
+	InFlight int            `json:"inFlight"`
+	Waiting  map[string]int `json:"waiting"`
+}
+
+// StatusReport is the payload served by the admin /status endpoint
+type StatusReport struct {
+	Sequencer    SequencerStatus        `json:"sequencer"`
+	Collector    CollectorStatus        `json:"collector"`
+	StreamPool   StreamPoolStats        `json:"streamPool"`
+	WritePermits WritePermitStatus      `json:"writePermits"`
+	QueuedAsync  map[string]int         `json:"queuedAsync,omitempty"`
+	CurrentEpoch uint64                 `json:"currentEpoch"`
+	Epochs       map[uint64]EpochStatus `json:"epochs"`
+	Simulation   EpochStatus            `json:"simulation"`
+	Stale        EpochStatus            `json:"stale"`
+	Summaries    []EpochSummary         `json:"epochSummaries,omitempty"`
+	Paused       bool                   `json:"paused"`
+	Connectivity ConnectivityReport     `json:"connectivity"`
+	ChainHead    *uint64                `json:"chainHead"` // null when unknown
+}
+
+// Status gathers a point-in-time view of the collector
+func (s *server) Status() StatusReport {
+	epochs := s.epochs.report()
+	report := StatusReport{
+		Sequencer:    sequencerStatus(),
+		Collector:    collectorStatus(),
+		StreamPool:   poolStats(),
+		CurrentEpoch: epochs.Latest,
+		Epochs:       epochs.Epochs,
+		Simulation:   epochs.Simulation,
+		Stale:        epochs.Stale,
+		Summaries:    epochs.Summaries,
+		Paused:       s.Paused(),
+		Connectivity: Connectivity(),
+	}
+
+	if head := currentChainHead(); head != nil {
+		if block, ok := head.CurrentBlock(); ok {
+			report.ChainHead = &block
+		}
+	}
+
+	permits := s.writePermits.stats()
+	report.WritePermits = WritePermitStatus{
+		Limit:    permits.Limit,
+		InFlight: permits.InFlight,
+		Waiting:  laneCounts(permits.Waiting),
+	}
+	if s.dispatcher != nil {
+		report.QueuedAsync = laneCounts(s.dispatcher.pending())
+	}
+	return report
+}
+
+// HealthReport is the payload served by the admin /healthz endpoint
+type HealthReport struct {
+	Healthy  bool     `json:"healthy"`
+	Version  string   `json:"version"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Health reports whether the collector can forward submissions. A paused
+// collector or a refresh in progress is still considered healthy.
+func (s *server) Health() HealthReport {
+	report := HealthReport{Version: config.Version}
+	sequencer := sequencerStatus()
+	if !sequencer.Refreshing && sequencer.Connectedness != network.Connected.String() {
+		report.Problems = append(report.Problems, fmt.Sprintf("sequencer not connected (%s)", sequencer.Connectedness))
+	}
+	if GetLibp2pStreamPool() == nil {
+		report.Problems = append(report.Problems, "stream pool not available")
+	}
+	report.Healthy = len(report.Problems) == 0
+	return report
+}
+
+func sequencerStatus() SequencerStatus {
+	sequencerMu.RLock()
+	defer sequencerMu.RUnlock()
+
+	status := SequencerStatus{
+		PeerID:      SequencerID.String(),
+		Multiaddr:   SequencerMaddr,
+		LastRefresh: lastConnectionRefresh,
+		Refreshing:  connectionRefreshing.Load(),
+	}
+	if SequencerHostConn != nil && SequencerID != "" {
+		status.Connectedness = SequencerHostConn.Network().Connectedness(SequencerID).String()
+	}
+	return status
+}
+
+func collectorStatus() CollectorStatus {
+	sequencerMu.RLock()
+	defer sequencerMu.RUnlock()
+
+	if SequencerHostConn == nil {
+		return CollectorStatus{}
+	}
+	status := CollectorStatus{PeerID: SequencerHostConn.ID().String()}
+	for _, addr := range SequencerHostConn.Addrs() {
+		status.ListenAddrs = append(status.ListenAddrs, addr.String())
+	}
+	return status
+}
+
+func laneCounts(counts [laneCount]int) map[string]int {
+	result := make(map[string]int, laneCount)
+	for l := submissionLane(0); l < laneCount; l++ {
+		result[l.String()] = counts[l]
+	}
+	return result
+}
+
+// StartAdminServer serves the admin/status API on the configured host and
+// port
+func StartAdminServer(s pkgs.SubmissionServer) {
+	if !config.Current().AdminEnabled {
+		return
+	}
+	srv, ok := s.(*server)
+	if !ok {
+		adminLog.Warn("Admin API is not supported for the provided server instance")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, srv.Status())
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		report := srv.Health()
+		code := http.StatusOK
+		if !report.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, report)
+	})
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, config.Current().Redacted())
+	})
+	mux.HandleFunc("/admin/loglevel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusOK, helpers.LogLevels())
+			return
+		}
+		adminAction(setLogLevel)(w, r)
+	})
+	mux.HandleFunc("/ledger", func(w http.ResponseWriter, r *http.Request) {
+		l := ledger.Load()
+		if l == nil {
+			writeJSON(w, http.StatusNotFound, adminResult{Error: "submission ledger is disabled"})
+			return
+		}
+		q, err := ParseLedgerQuery(r.URL.Query())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, adminResult{Error: err.Error()})
+			return
+		}
+		records, err := l.Query(q)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, adminResult{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, records)
+	})
+	registerControlHandlers(mux, srv)
+
+	settings := config.Current()
+	addr := net.JoinHostPort(settings.AdminHost, settings.AdminPort)
+	if settings.AdminToken == "" {
+		adminLog.Infof("🛠️ Admin server listening at %s", addr)
+	} else {
+		adminLog.Infof("🛠️ Admin server listening at %s, token required", addr)
+	}
+	if err := serveHTTP(addr, requireAdminToken(mux)); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		adminLog.Errorf("❌ Admin server stopped: %v", err)
+	}
+}
+
+// requireAdminToken refuses requests without the configured admin token as a
+// bearer token. Health checks stay open so container probes need no secret.
+func requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := config.Current().AdminToken
+		if token != "" && r.URL.Path != "/healthz" && !hasAdminToken(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, adminResult{Error: "admin token required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// controlAllowed reports whether r may change the collector's state: callers
+// already authenticated by requireAdminToken when a token is configured,
+// otherwise only local ones
+func controlAllowed(r *http.Request) bool {
+	if config.Current().AdminToken != "" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func hasAdminToken(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// registerControlHandlers mounts the runtime control operations. All of them
+// require POST.
+func registerControlHandlers(mux *http.ServeMux, srv *server) {
+	mux.HandleFunc("/admin/refresh", adminAction(func(r *http.Request) (int, interface{}) {
+		if err := RefreshSequencerConnection(); err != nil {
+			return http.StatusInternalServerError, adminResult{Error: err.Error()}
+		}
+		return http.StatusOK, adminResult{Status: "connection refreshed"}
+	}))
+	mux.HandleFunc("/admin/pause", adminAction(func(r *http.Request) (int, interface{}) {
+		srv.Pause()
+		return http.StatusOK, adminResult{Status: "ingest paused"}
+	}))
+	mux.HandleFunc("/admin/resume", adminAction(func(r *http.Request) (int, interface{}) {
+		srv.Resume()
+		return http.StatusOK, adminResult{Status: "ingest resumed"}
+	}))
+	mux.HandleFunc("/admin/pool/resize", adminAction(func(r *http.Request) (int, interface{}) {
+		size, err := strconv.Atoi(r.URL.Query().Get("size"))
+		if err != nil {
+			return http.StatusBadRequest, adminResult{Error: "size must be an integer"}
+		}
+		if err := ResizeStreamPool(size); err != nil {
+			return http.StatusBadRequest, adminResult{Error: err.Error()}
+		}
+		return http.StatusOK, adminResult{Status: fmt.Sprintf("stream pool resized to %d", size)}
+	}))
+	mux.HandleFunc("/admin/concurrency", adminAction(func(r *http.Request) (int, interface{}) {
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			return http.StatusBadRequest, adminResult{Error: "limit must be an integer"}
+		}
+		if err := srv.SetMaxConcurrentWrites(limit); err != nil {
+			return http.StatusBadRequest, adminResult{Error: err.Error()}
+		}
+		return http.StatusOK, adminResult{Status: fmt.Sprintf("max concurrent writes set to %d", limit)}
+	}))
+	mux.HandleFunc("/admin/chainhead", adminAction(func(r *http.Request) (int, interface{}) {
+		head, ok := currentChainHead().(*StaticChainHead)
+		if !ok {
+			return http.StatusConflict, adminResult{Error: "chain head can only be set with the static provider"}
+		}
+		block, err := strconv.ParseUint(r.URL.Query().Get("block"), 10, 64)
+		if err != nil {
+			return http.StatusBadRequest, adminResult{Error: "block must be an unsigned integer"}
+		}
+		head.Set(block)
+		return http.StatusOK, adminResult{Status: fmt.Sprintf("chain head set to block %d", block)}
+	}))
+	mux.HandleFunc("/admin/drain", adminAction(func(r *http.Request) (int, interface{}) {
+		timeout := 60 * time.Second
+		if raw := r.URL.Query().Get("timeout"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				return http.StatusBadRequest, adminResult{Error: "timeout must be a duration such as 30s"}
+			}
+			if parsed > maxDrainTimeout {
+				return http.StatusBadRequest, adminResult{Error: fmt.Sprintf("timeout must be at most %v", maxDrainTimeout)}
+			}
+			timeout = parsed
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		if err := srv.Drain(ctx); err != nil {
+			return http.StatusGatewayTimeout, adminResult{Error: err.Error()}
+		}
+		return http.StatusOK, adminResult{Status: "drained; ingest paused until /admin/resume"}
+	}))
+}
+
+// setLogLevel applies ?level=debug&subsystem=pool&ttl=10m. Subsystem defaults
+// to all and ttl to the configured override TTL.
+func setLogLevel(r *http.Request) (int, interface{}) {
+	query := r.URL.Query()
+	level, err := log.ParseLevel(query.Get("level"))
+	if err != nil {
+		return http.StatusBadRequest, adminResult{Error: err.Error()}
+	}
+	ttl := config.Current().LogLevelOverrideTTL
+	if raw := query.Get("ttl"); raw != "" {
+		if ttl, err = time.ParseDuration(raw); err != nil {
+			return http.StatusBadRequest, adminResult{Error: "ttl must be a duration such as 10m"}
+		}
+	}
+	if err := helpers.SetLogLevel(query.Get("subsystem"), level, ttl); err != nil {
+		return http.StatusBadRequest, adminResult{Error: err.Error()}
+	}
+	return http.StatusOK, helpers.LogLevels()
+}
+
+// adminResult is the body returned by control operations
+type adminResult struct {
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// adminAction restricts a control handler to POST from a trusted caller and
+// serialises its result
+func adminAction(action func(r *http.Request) (int, interface{})) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, adminResult{Error: "method not allowed"})
+			return
+		}
+		if !controlAllowed(r) {
+			adminLog.Warnf("🚫 Refused admin %s from %s", r.URL.Path, r.RemoteAddr)
+			writeJSON(w, http.StatusForbidden, adminResult{Error: "control operations require the admin token or a loopback caller"})
+			return
+		}
+		code, body := action(r)
+		adminLog.Infof("🛠️ Admin %s -> %d", r.URL.Path, code)
+		writeJSON(w, code, body)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		adminLog.Debugf("Failed to encode admin response: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"proto-snapshot-server/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	useSettings(t, &config.Settings{AdminToken: "s3cret"})
+	handler := requireAdminToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+	}{
+		{name: "Missing token", path: "/config", want: http.StatusUnauthorized},
+		{name: "Wrong token", path: "/ledger", authorization: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "Valid token", path: "/config", authorization: "Bearer s3cret", want: http.StatusOK},
+		{name: "Health check", path: "/healthz", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestControlRequiresLoopbackWithoutToken(t *testing.T) {
+	useSettings(t, &config.Settings{})
+	handler := adminAction(func(r *http.Request) (int, interface{}) {
+		return http.StatusOK, adminResult{Status: "done"}
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       int
+	}{
+		{name: "Loopback IPv4", remoteAddr: "127.0.0.1:40000", want: http.StatusOK},
+		{name: "Loopback IPv6", remoteAddr: "[::1]:40000", want: http.StatusOK},
+		{name: "Remote", remoteAddr: "203.0.113.7:40000", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	useSettings(t, &config.Settings{AdminToken: "s3cret"})
+	req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+	req.RemoteAddr = "203.0.113.7:40000"
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	requireAdminToken(handler).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
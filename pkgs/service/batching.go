@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs/helpers"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BatchFrameMagic opens every batch frame written when batching is enabled.
+//
+// A batch frame is laid out as:
+//
+//	magic   8 bytes    "PLBATCH1"
+//	count   uint32     number of entries, big-endian
+//	entries count x {
+//	    length   uint32   entry length in bytes, big-endian
+//	    payload  []byte   submission ID (UUID text) followed by the submission JSON
+//	}
+//
+// Each entry payload is exactly what a single unbatched write carries, so the
+// sequencer can split a frame and hand every entry to its existing parser.
+var BatchFrameMagic = []byte("PLBATCH1")
+
+// EncodeBatchFrame packs submission payloads into a single batch frame
+func EncodeBatchFrame(payloads [][]byte) []byte {
+	size := len(BatchFrameMagic) + 4
+	for _, p := range payloads {
+		size += 4 + len(p)
+	}
+
+	frame := make([]byte, 0, size)
+	frame = append(frame, BatchFrameMagic...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payloads)))
+	for _, p := range payloads {
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(p)))
+		frame = append(frame, p...)
+	}
+	return frame
+}
+
+// DecodeBatchFrame splits a batch frame back into its submission payloads
+func DecodeBatchFrame(frame []byte) ([][]byte, error) {
+	if !bytes.HasPrefix(frame, BatchFrameMagic) {
+		return nil, fmt.Errorf("missing batch frame magic")
+	}
+	rest := frame[len(BatchFrameMagic):]
+	if len(rest) < 4 {
+		return nil, fmt.Errorf("truncated batch frame header")
+	}
+	count := binary.BigEndian.Uint32(rest)
+	rest = rest[4:]
+	// Every entry needs at least its length prefix, so the header cannot
+	// claim more entries than the remaining bytes could hold
+	if uint64(count) > uint64(len(rest)/4) {
+		return nil, fmt.Errorf("batch frame claims %d entries but only has %d bytes", count, len(rest))
+	}
+
+	payloads := make([][]byte, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(rest) < 4 {
+			return nil, fmt.Errorf("truncated length for entry %d", i)
+		}
+		length := binary.BigEndian.Uint32(rest)
+		rest = rest[4:]
+		if uint64(len(rest)) < uint64(length) {
+			return nil, fmt.Errorf("truncated payload for entry %d: want %d bytes, have %d", i, length, len(rest))
+		}
+		payloads = append(payloads, rest[:length])
+		rest = rest[length:]
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after %d entries", len(rest), count)
+	}
+	return payloads, nil
+}
+
+// States of a batch entry. An entry is settled exactly once: the flush
+// claims it for writing, or its caller abandons it before that.
+const (
+	entryQueued int32 = iota
+	entryClaimed
+	entryAbandoned
+)
+
+// batchEntry is a submission waiting in the batcher along with its caller
+type batchEntry struct {
+	ctx   context.Context
+	item  *queuedSubmission
+	done  chan error
+	state atomic.Int32
+}
+
+// abandon drops the entry if no flush has claimed it yet
+func (e *batchEntry) abandon() bool {
+	return e.state.CompareAndSwap(entryQueued, entryAbandoned)
+}
+
+// claim takes the entry into a batch write if its caller has not given up
+func (e *batchEntry) claim() bool {
+	return e.state.CompareAndSwap(entryQueued, entryClaimed)
+}
+
+// submissionBatcher coalesces submissions arriving within a short window, or
+// up to a count or byte limit, into one batch frame per stream write
+type submissionBatcher struct {
+	server   *server
+	window   time.Duration
+	maxCount int
+	maxBytes int
+
+	incoming chan *batchEntry
+	flushes  sync.WaitGroup
+	stopped  chan struct{}
+
+	mu     sync.RWMutex // Guards closing incoming against concurrent submits
+	closed bool
+}
+
+func newSubmissionBatcher(s *server, window time.Duration, maxCount, maxBytes int) *submissionBatcher {
+	if maxCount <= 0 {
+		maxCount = 1
+	}
+	return &submissionBatcher{
+		server:   s,
+		window:   window,
+		maxCount: maxCount,
+		maxBytes: maxBytes,
+		incoming: make(chan *batchEntry, maxCount),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// submit adds a submission to the next batch and waits for that batch's
+// write. A caller that gives up before its batch is flushed drops the
+// submission; once the flush has claimed it, the caller gets the write's
+// outcome so it matches what the ledger and metrics record.
+func (b *submissionBatcher) submit(ctx context.Context, item *queuedSubmission) error {
+	entry := &batchEntry{ctx: ctx, item: item, done: make(chan error, 1)}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		err := status.Error(codes.Unavailable, "collector is shutting down")
+		b.server.recordOutcome(item, err)
+		return err
+	}
+	select {
+	case b.incoming <- entry:
+	case <-ctx.Done():
+		b.mu.RUnlock()
+		err := status.FromContextError(ctx.Err()).Err()
+		b.server.recordOutcome(item, err)
+		return err
+	}
+	b.mu.RUnlock()
+
+	select {
+	case err := <-entry.done:
+		return err
+	case <-ctx.Done():
+		if entry.abandon() {
+			err := status.FromContextError(ctx.Err()).Err()
+			b.server.recordOutcome(item, err)
+			return err
+		}
+		// Already being written, bounded by the batch's own timeout
+		return <-entry.done
+	}
+}
+
+func (b *submissionBatcher) run() {
+	defer close(b.stopped)
+
+	var pending []*batchEntry
+	pendingBytes := 0
+	var windowC <-chan time.Time
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		batch := pending
+		pending = nil
+		pendingBytes = 0
+		windowC = nil
+
+		b.flushes.Add(1)
+		go func() {
+			defer b.flushes.Done()
+			b.writeBatch(batch)
+		}()
+	}
+
+	for {
+		select {
+		case entry, ok := <-b.incoming:
+			if !ok {
+				flush()
+				return
+			}
+			size := 4 + len(entry.item.data)
+			if len(pending) > 0 && b.maxBytes > 0 && pendingBytes+size > b.maxBytes {
+				flush()
+			}
+			pending = append(pending, entry)
+			pendingBytes += size
+			if len(pending) == 1 {
+				windowC = time.After(b.window)
+			}
+			if len(pending) >= b.maxCount || (b.maxBytes > 0 && pendingBytes >= b.maxBytes) {
+				flush()
+			}
+		case <-windowC:
+			flush()
+		}
+	}
+}
+
+// writeBatch writes the entries of a batch whose callers are still waiting as
+// one frame and reports the outcome to each of them
+func (b *submissionBatcher) writeBatch(entries []*batchEntry) {
+	live := make([]*batchEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.ctx.Err() != nil && e.abandon() {
+			err := status.FromContextError(e.ctx.Err()).Err()
+			b.server.recordOutcome(e.item, err)
+			e.done <- err
+			continue
+		}
+		if e.claim() {
+			live = append(live, e)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	// Callers share the batch, so none of their deadlines bounds it
+	ctx, cancel := context.WithTimeout(context.Background(), config.Current().SubmissionTimeout)
+	defer cancel()
+
+	links := make([]trace.Link, 0, len(live))
+	for _, e := range live {
+		links = append(links, trace.Link{SpanContext: trace.SpanContextFromContext(e.ctx)})
+	}
+	ctx, span := tracer.Start(ctx, "write_batch", trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("batch.size", len(live))))
+
+	// The batch waits in the most urgent lane among its entries
+	lane := laneSimulation
+	payloads := make([][]byte, len(live))
+	for i, e := range live {
+		payloads[i] = e.item.data
+		if e.item.lane.outranks(lane) {
+			lane = e.item.lane
+		}
+	}
+	frame := EncodeBatchFrame(payloads)
+
+	batchId := uuid.New().String()
+	label := fmt.Sprintf("batch of %d submissions with ID: %s", len(live), batchId)
+	batchLog := grpcLog.WithField(helpers.FieldBatchID, batchId)
+	entry := batchLog
+	err := b.server.writeWithRetry(ctx, lane, func() error {
+		var err error
+		entry, err = writeFrame(ctx, frame, label, batchLog)
+		return err
+	})
+
+	span.SetAttributes(attribute.String("batch.id", batchId), attribute.Int("batch.bytes", len(frame)))
+	endSpan(span, err)
+
+	if err != nil {
+		entry.WithError(err).Errorf("❌ Failed to write %s", label)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = status.FromContextError(ctxErr).Err()
+		} else {
+			reportIssue(IssueStreamWriteFailure, nil, err, IssueDetails{
+				"batchId":   batchId,
+				"batchSize": len(live),
+			})
+		}
+	} else if b.server.successLogs.Sample() {
+		entry.WithField("bytes", len(frame)).Infof("✅ Successfully wrote %s", label)
+	}
+
+	for _, e := range live {
+		b.server.recordOutcome(e.item, err)
+		if err == nil {
+			submissionLogger(e.item.id, e.item.submission.Request).
+				WithField(helpers.FieldBatchID, batchId).Debug("✅ Submission written in batch")
+		}
+		e.done <- err
+	}
+}
+
+// stop flushes whatever is pending and waits for in-flight batch writes
+func (b *submissionBatcher) stop() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.incoming)
+	b.mu.Unlock()
+
+	<-b.stopped
+	b.flushes.Wait()
+	grpcLog.Info("✅ Submission batcher flushed")
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchFrameRoundTrip(t *testing.T) {
+	payloads := [][]byte{
+		[]byte(`6f1c2b8e-8a43-4b8e-9d1f-0c5b7a3e2d10{"request":{"epochId":1}}`),
+		{},
+		[]byte(`0a6d1f3c-2b5e-4c7d-8e9f-1a2b3c4d5e6f{"request":{"epochId":2}}`),
+	}
+
+	frame := EncodeBatchFrame(payloads)
+	decoded, err := DecodeBatchFrame(frame)
+	assert.NoError(t, err)
+	assert.Equal(t, len(payloads), len(decoded))
+	for i := range payloads {
+		assert.Equal(t, string(payloads[i]), string(decoded[i]))
+	}
+}
+
+func TestDecodeBatchFrameRejectsMalformedFrames(t *testing.T) {
+	valid := EncodeBatchFrame([][]byte{[]byte("payload")})
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{name: "Missing magic", frame: []byte("not a batch frame")},
+		{name: "Truncated header", frame: valid[:len(BatchFrameMagic)+2]},
+		{name: "Truncated payload", frame: valid[:len(valid)-1]},
+		{name: "Trailing bytes", frame: append(append([]byte{}, valid...), 0x00)},
+		{name: "Oversized count", frame: append(append([]byte{}, BatchFrameMagic...), 0xFF, 0xFF, 0xFF, 0xFF)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeBatchFrame(tt.frame)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// newTestBatcher returns a batcher that flushes every maxCount submissions
+// and when stopped, writing to a pool holding one fake stream
+func newTestBatcher(t *testing.T, maxCount int) (*submissionBatcher, *fakeStream, *epochMetrics) {
+	useSettings(t, &config.Settings{SubmissionTimeout: 5 * time.Second, StreamWriteTimeout: time.Second})
+	stream := newFakeStream("")
+	useTestPool(t, false, stream)
+
+	s := &server{
+		writePermits: newWritePermitScheduler(1, 10, 0, [laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1}),
+		epochs:       newEpochTracker(4),
+	}
+	s.batcher = newSubmissionBatcher(s, time.Hour, maxCount, 0)
+	go s.batcher.run()
+	return s.batcher, stream, s.epochs.metricsFor(1)
+}
+
+func batchItem(metrics *epochMetrics) *queuedSubmission {
+	return &queuedSubmission{
+		id:         "submission",
+		data:       []byte("payload"),
+		submission: &pkgs.SnapshotSubmission{Request: &pkgs.Request{EpochId: 1}},
+		metrics:    metrics,
+		lane:       laneCurrent,
+		enqueuedAt: time.Now(),
+	}
+}
+
+func TestBatcherDropsSubmissionsAbandonedBeforeFlush(t *testing.T) {
+	b, stream, metrics := newTestBatcher(t, 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := b.submit(ctx, batchItem(metrics))
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	b.stop()
+	assert.Zero(t, stream.written.Len(), "an abandoned submission must not be written")
+	assert.Equal(t, uint64(1), metrics.failed.Load())
+	assert.Zero(t, metrics.succeeded.Load())
+}
+
+func TestBatcherReportsWriteOutcomeOnceClaimed(t *testing.T) {
+	b, stream, metrics := newTestBatcher(t, 1)
+	defer b.stop()
+
+	// Hold the only write permit so the flushed batch waits for it
+	assert.NoError(t, b.server.writePermits.acquire(context.Background(), laneCurrent))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- b.submit(ctx, batchItem(metrics)) }()
+
+	assert.Eventually(t, func() bool { return b.server.writePermits.stats().Waiting[laneCurrent] == 1 },
+		time.Second, 10*time.Millisecond)
+	cancel()
+	b.server.writePermits.release(laneCurrent)
+
+	assert.NoError(t, <-result, "the caller is told the submission was written")
+	assert.NotZero(t, stream.written.Len())
+	assert.Equal(t, uint64(1), metrics.succeeded.Load())
+	assert.Zero(t, metrics.failed.Load())
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"proto-snapshot-server/config"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChainHeadProvider reports the latest block of the chain request deadlines
+// refer to
+type ChainHeadProvider interface {
+	// CurrentBlock returns the latest block, or false when it is not known
+	CurrentBlock() (uint64, bool)
+}
+
+// chainHead is the collector's chain head provider; nil when deadlines are
+// not enforced. It is replaced on config reload, so read it through
+// currentChainHead.
+var (
+	chainHead     ChainHeadProvider
+	chainHeadStop func()
+	chainHeadMu   sync.RWMutex
+)
+
+// currentChainHead returns the chain head provider, or nil
+func currentChainHead() ChainHeadProvider {
+	chainHeadMu.RLock()
+	defer chainHeadMu.RUnlock()
+	return chainHead
+}
+
+// InitChainHead starts the chain head provider selected in settings,
+// replacing any running one
+func InitChainHead(settings *config.Settings) error {
+	chainHeadMu.Lock()
+	defer chainHeadMu.Unlock()
+
+	if chainHeadStop != nil {
+		chainHeadStop()
+		chainHeadStop = nil
+	}
+
+	switch settings.ChainHeadProvider {
+	case "rpc":
+		poller := newRPCChainHead(settings.ChainRPCURL, settings.ChainPollInterval)
+		ctx, cancel := context.WithCancel(context.Background())
+		go poller.run(ctx)
+		chainHead, chainHeadStop = poller, cancel
+		connLog.Infof("⛓️ Following the chain head over JSON-RPC every %v", settings.ChainPollInterval)
+	case "static":
+		chainHead = NewStaticChainHead(uint64(settings.ChainStaticBlock))
+		connLog.Infof("⛓️ Using a static chain head at block %d", settings.ChainStaticBlock)
+	case "none", "":
+		chainHead = nil
+	default:
+		return fmt.Errorf("unknown chain head provider %q", settings.ChainHeadProvider)
+	}
+	return nil
+}
+
+// StopChainHead stops the chain head provider
+func StopChainHead() {
+	chainHeadMu.Lock()
+	defer chainHeadMu.Unlock()
+	if chainHeadStop != nil {
+		chainHeadStop()
+		chainHeadStop = nil
+	}
+	chainHead = nil
+}
+
+// StaticChainHead is a chain head that only moves when told to, for tests
+// and manual operation
+type StaticChainHead struct {
+	block atomic.Uint64
+}
+
+func NewStaticChainHead(block uint64) *StaticChainHead {
+	h := &StaticChainHead{}
+	h.block.Store(block)
+	return h
+}
+
+// CurrentBlock returns the block last set; block 0 means unknown
+func (h *StaticChainHead) CurrentBlock() (uint64, bool) {
+	block := h.block.Load()
+	return block, block > 0
+}
+
+// Set moves the chain head to block
+func (h *StaticChainHead) Set(block uint64) {
+	h.block.Store(block)
+}
+
+// rpcChainHead polls eth_blockNumber on an Ethereum JSON-RPC endpoint. A
+// head that has not been refreshed for a few intervals counts as unknown, so
+// an unreachable endpoint never causes submissions to be rejected.
+type rpcChainHead struct {
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	block     atomic.Uint64
+	updatedAt atomic.Int64 // UnixNano of the last successful poll
+	failing   bool         // Only touched by run
+}
+
+// Missed polls after which the chain head is considered unknown
+const chainHeadStalePolls = 3
+
+func newRPCChainHead(url string, interval time.Duration) *rpcChainHead {
+	return &rpcChainHead{
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: interval},
+	}
+}
+
+func (h *rpcChainHead) CurrentBlock() (uint64, bool) {
+	updatedAt := h.updatedAt.Load()
+	if updatedAt == 0 || time.Since(time.Unix(0, updatedAt)) > chainHeadStalePolls*h.interval {
+		return 0, false
+	}
+	return h.block.Load(), true
+}
+
+func (h *rpcChainHead) run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		h.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll refreshes the head, logging only when polling starts or stops failing
+func (h *rpcChainHead) poll(ctx context.Context) {
+	block, err := h.blockNumber(ctx)
+	if err != nil {
+		if ctx.Err() == nil && !h.failing {
+			connLog.Warnf("⚠️ Failed to fetch the chain head: %v", err)
+		}
+		h.failing = true
+		return
+	}
+	if h.failing {
+		connLog.Infof("✅ Chain head available again at block %d", block)
+	}
+	h.failing = false
+	h.block.Store(block)
+	h.updatedAt.Store(time.Now().UnixNano())
+}
+
+func (h *rpcChainHead) blockNumber(ctx context.Context) (uint64, error) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var result struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("invalid response: %w", err)
+	}
+	if result.Error != nil {
+		return 0, fmt.Errorf("rpc error %d: %s", result.Error.Code, result.Error.Message)
+	}
+	block, err := strconv.ParseUint(strings.TrimPrefix(result.Result, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid block number %q: %w", result.Result, err)
+	}
+	return block, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRPCChainHeadPollsBlockNumber(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1b4"}`))
+	}))
+	defer ts.Close()
+
+	head := newRPCChainHead(ts.URL, time.Minute)
+	_, ok := head.CurrentBlock()
+	assert.False(t, ok)
+
+	head.poll(context.Background())
+	block, ok := head.CurrentBlock()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(436), block)
+
+	// A head that is no longer refreshed becomes unknown
+	head.updatedAt.Store(time.Now().Add(-time.Hour).UnixNano())
+	_, ok = head.CurrentBlock()
+	assert.False(t, ok)
+}
+
+func TestDeadlineEnforcement(t *testing.T) {
+	useSettings(t, &config.Settings{DeadlineAction: "flag", DeadlineUrgentBlocks: 2})
+	head := NewStaticChainHead(100)
+	useChainHead(t, head)
+
+	expired := &pkgs.Request{EpochId: 1, Deadline: 99}
+	assert.NoError(t, checkDeadline("flagged", expired))
+
+	config.Current().DeadlineAction = "reject"
+	err := checkDeadline("rejected", expired)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, "past_deadline", failureReason(err))
+
+	// Requests without a deadline, or while the head is unknown, pass
+	assert.NoError(t, checkDeadline("none", &pkgs.Request{EpochId: 1}))
+	head.Set(0)
+	assert.NoError(t, checkDeadline("unknown", expired))
+
+	head.Set(100)
+	s := &server{epochs: newEpochTracker(4)}
+	assert.Equal(t, laneUrgent, s.laneFor(&pkgs.Request{EpochId: 5, Deadline: 102}))
+	assert.Equal(t, laneCurrent, s.laneFor(&pkgs.Request{EpochId: 5, Deadline: 103}))
+	assert.Equal(t, laneSimulation, s.laneFor(&pkgs.Request{Deadline: 101}))
+}
+
+func TestLanePickerServesUrgentFirst(t *testing.T) {
+	picker := newLanePicker([laneCount]int{laneCurrent: 8, laneLate: 3, laneSimulation: 1})
+	all := func(submissionLane) bool { return true }
+
+	lane, ok := picker.pick(all)
+	assert.True(t, ok)
+	assert.Equal(t, laneUrgent, lane)
+
+	lane, _ = picker.pick(func(l submissionLane) bool { return l != laneUrgent })
+	assert.Equal(t, laneCurrent, lane)
+
+	assert.True(t, laneUrgent.outranks(laneCurrent))
+	assert.True(t, laneCurrent.outranks(laneLate))
+	assert.False(t, laneSimulation.outranks(laneUrgent))
+}
+
+// useChainHead installs head as the chain head provider for the duration of
+// the test
+func useChainHead(t *testing.T, head ChainHeadProvider) {
+	chainHeadMu.Lock()
+	previous := chainHead
+	chainHead = head
+	chainHeadMu.Unlock()
+	t.Cleanup(func() {
+		chainHeadMu.Lock()
+		chainHead = previous
+		chainHeadMu.Unlock()
+	})
+}
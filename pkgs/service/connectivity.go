@@ -0,0 +1,192 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	ma "github.com/multiformats/go-multiaddr"
+	log "github.com/sirupsen/logrus"
+)
+
+// ConnectionInfo describes one libp2p connection to the sequencer
+type ConnectionInfo struct {
+	RemoteAddr string    `json:"remoteAddr"`
+	LocalAddr  string    `json:"localAddr"`
+	Direction  string    `json:"direction"`
+	Transport  string    `json:"transport"`
+	Security   string    `json:"security"`
+	Muxer      string    `json:"muxer"`
+	Relayed    bool      `json:"relayed"`
+	Transient  bool      `json:"transient"`
+	Opened     time.Time `json:"opened"`
+	Streams    int       `json:"streams"`
+}
+
+// ConnectivityReport summarises how reachable this node is and how it is
+// connected to the sequencer
+type ConnectivityReport struct {
+	Reachability   string            `json:"reachability"`
+	NATDeviceTypes map[string]string `json:"natDeviceTypes,omitempty"`
+	ObservedAddrs  []string          `json:"observedAddrs"`
+	SequencerConns []ConnectionInfo  `json:"sequencerConns"`
+}
+
+// natState holds what AutoNAT and identify have told us about our host
+type natState struct {
+	mu             sync.RWMutex
+	reachability   network.Reachability
+	natDeviceTypes map[string]string
+}
+
+var nat = &natState{natDeviceTypes: make(map[string]string)}
+
+// watchConnectivity logs reachability, NAT type and address changes reported
+// on the host's event bus. Closing the host does not end the subscription, so
+// any previous one is closed first. Callers hold sequencerMu.
+func watchConnectivity(h host.Host) {
+	stopWatchingConnectivity()
+
+	sub, err := h.EventBus().Subscribe([]interface{}{
+		new(event.EvtLocalReachabilityChanged),
+		new(event.EvtNATDeviceTypeChanged),
+		new(event.EvtLocalAddressesUpdated),
+	})
+	if err != nil {
+		connLog.Warnf("Failed to subscribe to connectivity events: %v", err)
+		return
+	}
+
+	nat.mu.Lock()
+	nat.reachability = network.ReachabilityUnknown
+	nat.natDeviceTypes = make(map[string]string)
+	nat.mu.Unlock()
+
+	connectivitySub = sub
+	go func() {
+		for e := range sub.Out() {
+			handleConnectivityEvent(e)
+		}
+	}()
+}
+
+// stopWatchingConnectivity closes the event bus subscription of the current
+// host, ending its watcher goroutine. Callers hold sequencerMu.
+func stopWatchingConnectivity() {
+	if connectivitySub == nil {
+		return
+	}
+	if err := connectivitySub.Close(); err != nil {
+		connLog.Warnf("Failed to close connectivity event subscription: %v", err)
+	}
+	connectivitySub = nil
+}
+
+func handleConnectivityEvent(e interface{}) {
+	switch evt := e.(type) {
+	case event.EvtLocalReachabilityChanged:
+		nat.mu.Lock()
+		nat.reachability = evt.Reachability
+		nat.mu.Unlock()
+		connLog.WithField("reachability", evt.Reachability.String()).Info("🌐 AutoNAT reachability changed")
+	case event.EvtNATDeviceTypeChanged:
+		nat.mu.Lock()
+		nat.natDeviceTypes[evt.TransportProtocol.String()] = evt.NatDeviceType.String()
+		nat.mu.Unlock()
+		connLog.WithFields(log.Fields{
+			"transport": evt.TransportProtocol.String(),
+			"nat_type":  evt.NatDeviceType.String(),
+		}).Info("🌐 NAT device type detected")
+	case event.EvtLocalAddressesUpdated:
+		var addrs []string
+		for _, a := range evt.Current {
+			addrs = append(addrs, a.Address.String())
+		}
+		connLog.WithField("addrs", addrs).Debug("🌐 Local addresses updated")
+	}
+}
+
+// Connectivity reports the current reachability and sequencer connections
+func Connectivity() ConnectivityReport {
+	nat.mu.RLock()
+	report := ConnectivityReport{
+		Reachability:   nat.reachability.String(),
+		NATDeviceTypes: make(map[string]string, len(nat.natDeviceTypes)),
+	}
+	for k, v := range nat.natDeviceTypes {
+		report.NATDeviceTypes[k] = v
+	}
+	nat.mu.RUnlock()
+
+	sequencerMu.RLock()
+	h, sequencerID := SequencerHostConn, SequencerID
+	sequencerMu.RUnlock()
+	if h == nil {
+		return report
+	}
+
+	report.ObservedAddrs = observedAddrs(h)
+	if sequencerID != "" {
+		report.SequencerConns = DescribeConnections(h, sequencerID)
+	}
+	return report
+}
+
+// observedAddrs returns the addresses peers have seen us dial from
+func observedAddrs(h host.Host) []string {
+	ids, ok := h.(interface{ IDService() identify.IDService })
+	if !ok || ids.IDService() == nil {
+		return nil
+	}
+	var addrs []string
+	for _, a := range ids.IDService().OwnObservedAddrs() {
+		addrs = append(addrs, a.String())
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// DescribeConnections lists the open connections from h to p
+func DescribeConnections(h host.Host, p peer.ID) []ConnectionInfo {
+	var conns []ConnectionInfo
+	for _, c := range h.Network().ConnsToPeer(p) {
+		state, stat := c.ConnState(), c.Stat()
+		conns = append(conns, ConnectionInfo{
+			RemoteAddr: c.RemoteMultiaddr().String(),
+			LocalAddr:  c.LocalMultiaddr().String(),
+			Direction:  stat.Direction.String(),
+			Transport:  state.Transport,
+			Security:   string(state.Security),
+			Muxer:      string(state.StreamMultiplexer),
+			Relayed:    isRelayed(c.RemoteMultiaddr()),
+			Transient:  stat.Transient,
+			Opened:     stat.Opened,
+			Streams:    stat.NumStreams,
+		})
+	}
+	return conns
+}
+
+func isRelayed(addr ma.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(ma.P_CIRCUIT)
+	return err == nil
+}
+
+// logSequencerConns logs how h is connected to the sequencer
+func logSequencerConns(h host.Host, p peer.ID) {
+	for _, c := range DescribeConnections(h, p) {
+		connLog.WithFields(log.Fields{
+			"remote_addr": c.RemoteAddr,
+			"direction":   c.Direction,
+			"transport":   c.Transport,
+			"security":    c.Security,
+			"muxer":       c.Muxer,
+			"relayed":     c.Relayed,
+		}).Info("🔗 Sequencer connection established")
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeConnectionsReportsNegotiatedProtocols(t *testing.T) {
+	server, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	assert.NoError(t, err)
+	defer server.Close()
+	client, err := libp2p.New(libp2p.NoListenAddrs)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: server.Addrs()}))
+
+	conns := DescribeConnections(client, server.ID())
+	if assert.Len(t, conns, 1) {
+		assert.Equal(t, "tcp", conns[0].Transport)
+		assert.Equal(t, "Outbound", conns[0].Direction)
+		assert.NotEmpty(t, conns[0].Security)
+		assert.NotEmpty(t, conns[0].Muxer)
+		assert.False(t, conns[0].Relayed)
+	}
+}
+
+func TestIsRelayed(t *testing.T) {
+	relayed := ma.StringCast("/ip4/1.2.3.4/tcp/9100/p2p/QmTK9e9QNEotPkjWAdZT5bbYKV7PEJVu7iXzdVn3VZDEk9/p2p-circuit")
+	assert.True(t, isRelayed(relayed))
+	assert.False(t, isRelayed(ma.StringCast("/ip4/1.2.3.4/tcp/9100")))
+}
+
+func TestWatchConnectivityDoesNotLeakOnRefresh(t *testing.T) {
+	h, err := libp2p.New(libp2p.NoListenAddrs)
+	assert.NoError(t, err)
+	defer h.Close()
+
+	sequencerMu.Lock()
+	defer sequencerMu.Unlock()
+	t.Cleanup(func() {
+		sequencerMu.Lock()
+		stopWatchingConnectivity()
+		sequencerMu.Unlock()
+	})
+
+	watchConnectivity(h)
+	time.Sleep(50 * time.Millisecond)
+	baseline := runtime.NumGoroutine()
+
+	for i := 0; i < 50; i++ {
+		watchConnectivity(h)
+	}
+	assert.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= baseline+2
+	}, 2*time.Second, 20*time.Millisecond, "goroutines grew from %d to %d", baseline, runtime.NumGoroutine())
+}
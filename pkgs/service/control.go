@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Pause stops accepting new submissions; work already accepted continues
+func (s *server) Pause() {
+	if !s.paused.Swap(true) {
+		grpcLog.Warn("⏸️ Submission ingest paused")
+	}
+}
+
+// Resume accepts submissions again after Pause or Drain
+func (s *server) Resume() {
+	if s.paused.Swap(false) {
+		grpcLog.Info("▶️ Submission ingest resumed")
+	}
+}
+
+// Paused reports whether ingest is currently paused
+func (s *server) Paused() bool {
+	return s.paused.Load()
+}
+
+// SetMaxConcurrentWrites changes the write concurrency limit live
+func (s *server) SetMaxConcurrentWrites(limit int) error {
+	if limit <= 0 {
+		return fmt.Errorf("max concurrent writes must be positive, got %d", limit)
+	}
+	s.writePermits.setLimit(limit)
+	grpcLog.Infof("📐 Max concurrent writes set to %d", limit)
+	return nil
+}
+
+// Drain pauses ingest and waits until no accepted submission is queued or
+// being written. Ingest stays paused afterwards until Resume is called.
+func (s *server) Drain(ctx context.Context) error {
+	s.Pause()
+	grpcLog.Info("🚰 Draining in-flight submissions")
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if s.idle() {
+			grpcLog.Info("✅ Collector drained")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("drain incomplete (%d submissions still delivering): %w", s.delivering.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// idle reports whether nothing is queued or being written
+func (s *server) idle() bool {
+	if s.dispatcher != nil {
+		return s.dispatcher.idle()
+	}
+	return s.delivering.Load() == 0
+}
+
+// ResizeStreamPool changes how many idle streams the pool keeps
+func ResizeStreamPool(maxSize int) error {
+	if maxSize <= 0 {
+		return fmt.Errorf("stream pool size must be positive, got %d", maxSize)
+	}
+	pool := GetLibp2pStreamPool()
+	if pool == nil {
+		return fmt.Errorf("stream pool not available")
+	}
+	pool.Resize(maxSize)
+	return nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"proto-snapshot-server/pkgs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainWaitsForDequeuedSubmissions(t *testing.T) {
+	s := &server{epochs: newEpochTracker(4)}
+	s.dispatcher = newSubmissionDispatcher(s, 10, [laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1})
+	assert.NoError(t, s.dispatcher.enqueue(&queuedSubmission{
+		id:         "queued",
+		submission: &pkgs.SnapshotSubmission{Request: &pkgs.Request{EpochId: 1}},
+		lane:       laneCurrent,
+	}))
+
+	// A worker has taken the submission off the queue but not written it yet
+	_, ok := s.dispatcher.next()
+	assert.True(t, ok)
+	assert.Equal(t, [laneCount]int{}, s.dispatcher.pending())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	assert.Error(t, s.Drain(ctx))
+	assert.True(t, s.Paused())
+
+	s.delivering.Add(-1)
+	assert.NoError(t, s.Drain(context.Background()))
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// blocksToDeadline returns how many blocks remain before request's deadline,
+// negative once it has passed. It is false when the request has no deadline
+// or the chain head is not known.
+func blocksToDeadline(request *pkgs.Request) (int64, bool) {
+	head := currentChainHead()
+	if head == nil || request.Deadline == 0 {
+		return 0, false
+	}
+	block, ok := head.CurrentBlock()
+	if !ok {
+		return 0, false
+	}
+	return int64(request.Deadline) - int64(block), true
+}
+
+// nearDeadline reports whether request must be written within the configured
+// number of urgent blocks to make its deadline
+func nearDeadline(request *pkgs.Request) bool {
+	remaining, ok := blocksToDeadline(request)
+	return ok && remaining >= 0 && remaining <= int64(config.Current().DeadlineUrgentBlocks)
+}
+
+// checkDeadline flags submissions whose deadline block has passed and, when
+// the deadline action is "reject", refuses them before they reach the stream
+// pool
+func checkDeadline(submissionId string, request *pkgs.Request) error {
+	remaining, ok := blocksToDeadline(request)
+	if !ok || remaining >= 0 {
+		return nil
+	}
+	reject := config.Current().DeadlineAction == "reject"
+	action := "flagged"
+	if reject {
+		action = "rejected"
+	}
+	submissionsPastDeadline.WithLabelValues(action).Inc()
+
+	head := uint64(int64(request.Deadline) - remaining)
+	submissionLogger(submissionId, request).Warnf("⏰ Submission %s: deadline block %d passed, chain head is %d",
+		action, request.Deadline, head)
+	reportIssue(IssueDeadlinePassed, request, nil, IssueDetails{
+		"slotId":        request.SlotId,
+		"deadline":      request.Deadline,
+		"chainHead":     head,
+		"blocksOverdue": -remaining,
+		"action":        action,
+	})
+
+	if reject {
+		return status.Errorf(codes.FailedPrecondition, "submission deadline block %d has passed (chain head %d)", request.Deadline, head)
+	}
+	return nil
+}
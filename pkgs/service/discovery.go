@@ -15,7 +15,6 @@ import (
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	ma "github.com/multiformats/go-multiaddr"
-	log "github.com/sirupsen/logrus"
 )
 
 type Relayer struct {
@@ -32,26 +31,35 @@ type Sequencer struct {
 	Environment       string `json:"environment"`
 }
 
+// ResolveSequencer looks up the sequencer serving the configured data market
+func ResolveSequencer() (Sequencer, error) {
+	return fetchSequencer(config.Current().SequencersListUrl, config.Current().DataMarketAddress)
+}
+
 func fetchSequencer(url string, dataMarketAddress string) (Sequencer, error) {
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Fatalf("Failed to fetch JSON: %v", err)
+		return Sequencer{}, errors.Wrap(err, "failed to fetch sequencer list")
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return Sequencer{}, errors.Errorf("failed to fetch sequencer list from %s: %s", url, resp.Status)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Debugf("Failed to read response body: %v", err)
+		return Sequencer{}, errors.Wrap(err, "failed to read sequencer list")
 	}
 
 	var sequencers []Sequencer
 	err = json.Unmarshal(body, &sequencers)
 	if err != nil {
-		log.Debugln("Failed to unmarshal JSON:", err)
+		return Sequencer{}, errors.Wrap(err, "failed to parse sequencer list")
 	}
 
 	for _, sequencer := range sequencers {
-		log.Debugf(
+		discoveryLog.Debugf(
 			"ID: %s, Maddr: %s, Data Market Address: %s, Environment: %s\n",
 			sequencer.ID,
 			sequencer.Maddr,
@@ -70,23 +78,23 @@ func fetchSequencer(url string, dataMarketAddress string) (Sequencer, error) {
 func fetchTrustedRelayers(url string) []Relayer {
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Fatalf("Failed to fetch JSON: %v", err)
+		discoveryLog.Fatalf("Failed to fetch JSON: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Debugf("Failed to read response body: %v", err)
+		discoveryLog.Debugf("Failed to read response body: %v", err)
 	}
 
 	var relayers []Relayer
 	err = json.Unmarshal(body, &relayers)
 	if err != nil {
-		log.Debugln("Failed to unmarshal JSON:", err)
+		discoveryLog.Debugln("Failed to unmarshal JSON:", err)
 	}
 
 	for _, relayer := range relayers {
-		log.Debugf("ID: %s, Name: %s, Rendezvous Point: %s, Maddr: %s\n", relayer.ID, relayer.Name, relayer.RendezvousPoint, relayer.Maddr)
+		discoveryLog.Debugf("ID: %s, Name: %s, Rendezvous Point: %s, Maddr: %s\n", relayer.ID, relayer.Name, relayer.RendezvousPoint, relayer.Maddr)
 	}
 
 	return relayers
@@ -95,32 +103,32 @@ func fetchTrustedRelayers(url string) []Relayer {
 func AddPeerConnection(ctx context.Context, host host.Host, relayerAddr string) bool {
 	stableRelayerMA, err := ma.NewMultiaddr(relayerAddr)
 	if err != nil {
-		log.Debugln("Failed to parse stable peer multiaddress: ", err)
+		discoveryLog.Debugln("Failed to parse stable peer multiaddress: ", err)
 	}
 
 	peerInfo, err := peer.AddrInfoFromP2pAddr(stableRelayerMA)
 	if err != nil {
-		log.Debugln("Failed to extract peer info from multiaddress:", err)
+		discoveryLog.Debugln("Failed to extract peer info from multiaddress:", err)
 	}
 
 	if host.Network().Connectedness(peerInfo.ID) == network.Connected {
-		log.Debugln("Skipping connected relayer: ", peerInfo.ID)
+		discoveryLog.Debugln("Skipping connected relayer: ", peerInfo.ID)
 		return true
 	}
 
 	err = host.Connect(ctx, *peerInfo)
 	if err != nil {
-		log.Errorf("Failed to connect to relayer %s: %s", peerInfo.ID, err)
+		discoveryLog.Errorf("Failed to connect to relayer %s: %s", peerInfo.ID, err)
 		return false
 	} else {
-		log.Infof("Connected to new relayer: %s", peerInfo.ID)
-		log.Infoln("Connected: ", host.Network().ConnsToPeer(peerInfo.ID))
+		discoveryLog.Infof("Connected to new relayer: %s", peerInfo.ID)
+		discoveryLog.Infoln("Connected: ", host.Network().ConnsToPeer(peerInfo.ID))
 		return true
 	}
 }
 
 func ConnectToTrustedRelayers(ctx context.Context, host host.Host) []Relayer {
-	relayers := fetchTrustedRelayers(config.SettingsObj.TrustedRelayersListUrl)
+	relayers := fetchTrustedRelayers(config.Current().TrustedRelayersListUrl)
 	var connectedRelayers []Relayer
 
 	for _, relayer := range relayers {
@@ -136,12 +144,12 @@ func ConfigureDHT(ctx context.Context, host host.Host) *dht.IpfsDHT {
 	// Set up a Kademlia DHT for the service host
 	kademliaDHT, err := dht.New(ctx, host)
 	if err != nil {
-		log.Fatalf("Failed to create DHT: %s", err)
+		discoveryLog.Fatalf("Failed to create DHT: %s", err)
 	}
 
 	// Bootstrap the DHT
 	if err = kademliaDHT.Bootstrap(ctx); err != nil {
-		log.Fatalf("Failed to bootstrap DHT: %s", err)
+		discoveryLog.Fatalf("Failed to bootstrap DHT: %s", err)
 	}
 
 	var wg sync.WaitGroup
@@ -151,13 +159,13 @@ func ConfigureDHT(ctx context.Context, host host.Host) *dht.IpfsDHT {
 		go func() {
 			defer wg.Done()
 			if err := host.Connect(ctx, *peerinfo); err != nil {
-				log.Warning(err)
+				discoveryLog.Warning(err)
 			} else {
-				log.Debugln("Connection established with bootstrap node:", *peerinfo)
+				discoveryLog.Debugln("Connection established with bootstrap node:", *peerinfo)
 			}
 		}()
 	}
 	wg.Wait()
 
 	return kademliaDHT
-}
\ No newline at end of file
+}
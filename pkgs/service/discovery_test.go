@@ -109,9 +109,9 @@ func TestConnectToTrustedRelayers(t *testing.T) {
 
 	defer ts.Close()
 
-	config.SettingsObj = &config.Settings{
+	useSettings(t, &config.Settings{
 		TrustedRelayersListUrl: ts.URL,
-	}
+	})
 
 	host, err := libp2p.New()
 	if err != nil {
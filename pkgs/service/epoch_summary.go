@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Why an epoch was closed
+const (
+	epochClosedQuiet    = "quiet_period"
+	epochClosedDeadline = "deadline"
+	epochClosedEvicted  = "evicted"
+)
+
+// Closed epoch summaries kept for the status API
+const recentEpochSummaries = 10
+
+// EpochSummary describes the submissions of an epoch once it is closed.
+// Submissions arriving after that are still counted in the epoch metrics but
+// not in its summary.
+type EpochSummary struct {
+	EpochID         uint64            `json:"epochId"`
+	ClosedAt        time.Time         `json:"closedAt"`
+	Reason          string            `json:"reason"`
+	Received        uint64            `json:"received"`
+	Succeeded       uint64            `json:"succeeded"`
+	Failed          uint64            `json:"failed"`
+	Pending         uint64            `json:"pending"`
+	Failures        map[string]uint64 `json:"failures,omitempty"`
+	LatencyP50Ms    float64           `json:"latencyP50Ms"`
+	LatencyP99Ms    float64           `json:"latencyP99Ms"`
+	Projects        []string          `json:"projects"`
+	SlotIDs         []uint64          `json:"slotIds"`
+	PreviousEpoch   uint64            `json:"previousEpoch,omitempty"`
+	MissingProjects []string          `json:"missingProjects,omitempty"`
+}
+
+type closingEpoch struct {
+	epochID uint64
+	metrics *epochMetrics
+	reason  string
+}
+
+// summary snapshots the metrics of a closing epoch
+func (m *epochMetrics) summary(epochID uint64, reason string, now time.Time) EpochSummary {
+	status := m.status()
+	summary := EpochSummary{
+		EpochID:      epochID,
+		ClosedAt:     now,
+		Reason:       reason,
+		Received:     status.Received,
+		Succeeded:    status.Succeeded,
+		Failed:       status.Failed,
+		Failures:     status.Failures,
+		LatencyP50Ms: status.LatencyP50Ms,
+		LatencyP99Ms: status.LatencyP99Ms,
+		Projects:     []string{},
+		SlotIDs:      []uint64{},
+	}
+	if done := summary.Succeeded + summary.Failed; summary.Received > done {
+		summary.Pending = summary.Received - done
+	}
+
+	m.mu.Lock()
+	for projectID := range m.projects {
+		summary.Projects = append(summary.Projects, projectID)
+	}
+	for slotID := range m.slots {
+		summary.SlotIDs = append(summary.SlotIDs, slotID)
+	}
+	m.mu.Unlock()
+
+	sort.Strings(summary.Projects)
+	sort.Slice(summary.SlotIDs, func(i, j int) bool { return summary.SlotIDs[i] < summary.SlotIDs[j] })
+	return summary
+}
+
+// closeEpochs closes the epochs that had no submission for quietPeriod or
+// whose deadline, counted from their first submission, passed. A zero
+// deadline disables it. Epochs evicted from the window before closing are
+// closed too. It returns their summaries, oldest epoch first.
+func (t *epochTracker) closeEpochs(now time.Time, quietPeriod, deadline time.Duration) []EpochSummary {
+	t.mu.Lock()
+	closing := t.evicted
+	t.evicted = nil
+	for epochID, m := range t.epochs {
+		if m.closed {
+			continue
+		}
+		var reason string
+		switch {
+		case deadline > 0 && now.Sub(m.firstSeen) >= deadline:
+			reason = epochClosedDeadline
+		case now.Sub(time.Unix(0, m.lastSeen.Load())) >= quietPeriod:
+			reason = epochClosedQuiet
+		default:
+			continue
+		}
+		m.closed = true
+		closing = append(closing, closingEpoch{epochID, m, reason})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(closing, func(i, j int) bool { return closing[i].epochID < closing[j].epochID })
+	summaries := make([]EpochSummary, 0, len(closing))
+	for _, c := range closing {
+		summary := c.metrics.summary(c.epochID, c.reason, now)
+
+		t.mu.Lock()
+		if previous, ok := t.previousClosed(c.epochID); ok {
+			summary.PreviousEpoch = previous
+			summary.MissingProjects = missingProjects(t.closedProjects[previous], summary.Projects)
+		}
+		t.closedProjects[c.epochID] = summary.Projects
+		for len(t.closedProjects) > int(t.window)+1 {
+			oldest := c.epochID
+			for epochID := range t.closedProjects {
+				if epochID < oldest {
+					oldest = epochID
+				}
+			}
+			delete(t.closedProjects, oldest)
+		}
+		t.summaries = append(t.summaries, summary)
+		if len(t.summaries) > recentEpochSummaries {
+			t.summaries = t.summaries[len(t.summaries)-recentEpochSummaries:]
+		}
+		t.mu.Unlock()
+
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// previousClosed returns the highest closed epoch below epochID; callers
+// hold t.mu
+func (t *epochTracker) previousClosed(epochID uint64) (uint64, bool) {
+	var previous uint64
+	found := false
+	for closedID := range t.closedProjects {
+		if closedID < epochID && (!found || closedID > previous) {
+			previous, found = closedID, true
+		}
+	}
+	return previous, found
+}
+
+// missingProjects returns the projects of previous that are not in current;
+// both are sorted
+func missingProjects(previous, current []string) []string {
+	var missing []string
+	for _, projectID := range previous {
+		if i := sort.SearchStrings(current, projectID); i == len(current) || current[i] != projectID {
+			missing = append(missing, projectID)
+		}
+	}
+	return missing
+}
+
+// closeEpochsPeriodically checks for closed epochs and emits their summaries
+// until ctx is done
+func (s *server) closeEpochsPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		summaries := s.epochs.closeEpochs(time.Now(),
+			config.Current().EpochQuietPeriod, config.Current().EpochSubmissionDeadline)
+		for _, summary := range summaries {
+			emitEpochSummary(summary)
+		}
+	}
+}
+
+// emitEpochSummary logs a summary, queues it for the reporting service when
+// epoch summary reporting is enabled and reports projects that stopped
+// submitting
+func emitEpochSummary(summary EpochSummary) {
+	grpcLog.WithFields(log.Fields{
+		"epoch_id":       summary.EpochID,
+		"reason":         summary.Reason,
+		"projects":       len(summary.Projects),
+		"slots":          len(summary.SlotIDs),
+		"received":       summary.Received,
+		"succeeded":      summary.Succeeded,
+		"failed":         summary.Failed,
+		"pending":        summary.Pending,
+		"failures":       summary.Failures,
+		"latency_p50_ms": summary.LatencyP50Ms,
+		"latency_p99_ms": summary.LatencyP99Ms,
+	}).Info("🏁 Epoch closed")
+
+	if len(summary.MissingProjects) > 0 {
+		err := fmt.Errorf("%d project(s) submitted in epoch %d but not in epoch %d",
+			len(summary.MissingProjects), summary.PreviousEpoch, summary.EpochID)
+		grpcLog.Warnf("⚠️ %v: %v", err, summary.MissingProjects)
+		reportIssue(IssueProjectsMissing, &pkgs.Request{EpochId: summary.EpochID}, err, IssueDetails{
+			"previousEpoch":   summary.PreviousEpoch,
+			"missingProjects": summary.MissingProjects,
+		})
+	}
+
+	if reporter := reportingInstance.Load(); reporter != nil && config.Current().EpochSummaryReporting {
+		reporter.ReportEpochSummary(summary)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEpochSummaryFlagsMissingProjects(t *testing.T) {
+	tracker := newEpochTracker(4)
+	for _, request := range []*pkgs.Request{
+		{EpochId: 5, SlotId: 1, ProjectId: "alpha"},
+		{EpochId: 5, SlotId: 2, ProjectId: "beta"},
+		{EpochId: 6, SlotId: 1, ProjectId: "alpha"},
+	} {
+		m := tracker.metricsFor(request.EpochId)
+		m.receive(request)
+		m.succeeded.Add(1)
+	}
+
+	// Nothing is quiet yet
+	assert.Empty(t, tracker.closeEpochs(time.Now(), time.Minute, 0))
+
+	summaries := tracker.closeEpochs(time.Now().Add(2*time.Minute), time.Minute, 0)
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, uint64(5), summaries[0].EpochID)
+	assert.Equal(t, []string{"alpha", "beta"}, summaries[0].Projects)
+	assert.Equal(t, []uint64{1, 2}, summaries[0].SlotIDs)
+	assert.Empty(t, summaries[0].MissingProjects)
+
+	assert.Equal(t, epochClosedQuiet, summaries[1].Reason)
+	assert.Equal(t, uint64(5), summaries[1].PreviousEpoch)
+	assert.Equal(t, []string{"beta"}, summaries[1].MissingProjects)
+
+	// Closed epochs are summarised once and listed in the report
+	assert.Empty(t, tracker.closeEpochs(time.Now().Add(time.Hour), time.Minute, 0))
+	report := tracker.report()
+	assert.Len(t, report.Summaries, 2)
+	assert.True(t, report.Epochs[6].Closed)
+}
+
+func TestEpochClosesAtDeadlineOrEviction(t *testing.T) {
+	tracker := newEpochTracker(1)
+	tracker.metricsFor(1).receive(&pkgs.Request{EpochId: 1, ProjectId: "alpha"})
+
+	summaries := tracker.closeEpochs(time.Now().Add(time.Minute), time.Hour, 30*time.Second)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, epochClosedDeadline, summaries[0].Reason)
+
+	tracker.metricsFor(2).receive(&pkgs.Request{EpochId: 2, ProjectId: "alpha"})
+	tracker.metricsFor(3).receive(&pkgs.Request{EpochId: 3, ProjectId: "beta"})
+	summaries = tracker.closeEpochs(time.Now(), time.Hour, 0)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, epochClosedEvicted, summaries[0].Reason)
+	assert.Equal(t, uint64(2), summaries[0].EpochID)
+	assert.Empty(t, summaries[0].MissingProjects)
+}
+
+func TestCloseEpochsPeriodicallyStopsWithContext(t *testing.T) {
+	s := &server{epochs: newEpochTracker(4)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.closeEpochsPeriodically(ctx, time.Hour)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("closeEpochsPeriodically kept running after its context was cancelled")
+	}
+}
+
+func TestEmitEpochSummaryDoesNotWaitForTheBackend(t *testing.T) {
+	useSettings(t, &config.Settings{EpochSummaryReporting: true})
+	received := make(chan EpochSummary, 1)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		var summary EpochSummary
+		json.NewDecoder(r.Body).Decode(&summary)
+		assert.Equal(t, "/epochSummary", r.URL.Path)
+		received <- summary
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{queueSize: 10, batchSize: 1, ratePerMinute: 10})
+	go reporter.run()
+	defer reporter.Stop()
+	previous := reportingInstance.Swap(reporter)
+	t.Cleanup(func() { reportingInstance.Store(previous) })
+
+	start := time.Now()
+	emitEpochSummary(EpochSummary{EpochID: 42})
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	select {
+	case summary := <-received:
+		assert.Equal(t, uint64(42), summary.EpochID)
+	case <-time.After(2 * time.Second):
+		t.Fatal("epoch summary was not delivered")
+	}
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"proto-snapshot-server/pkgs"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Completed submissions sampled per epoch for latency percentiles
+const latencyReservoirSize = 1024
+
+// Defaults of the guard against stray epoch IDs, see setJumpGuard
+const (
+	defaultEpochMaxAdvance        = 1000
+	defaultEpochJumpConfirmations = 2
+	defaultEpochAnchorTimeout     = 5 * time.Minute
+)
+
+// epochMetrics tracks submission statistics for a specific epoch
+type epochMetrics struct {
+	received  atomic.Uint64
+	succeeded atomic.Uint64
+	failed    atomic.Uint64
+
+	firstSeen time.Time
+	lastSeen  atomic.Int64 // UnixNano of the latest submission
+	closed    bool         // Guarded by the tracker's mu
+
+	mu        sync.Mutex
+	latencies []time.Duration // Reservoir sample of completion latencies
+	observed  uint64          // Latencies offered to the reservoir
+	failures  map[string]uint64
+	projects  map[string]struct{} // Nil for the simulation and stale buckets
+	slots     map[uint64]struct{}
+}
+
+// newEpochMetrics creates the metrics of a tracked epoch, which also records
+// the projects and slots that submitted
+func newEpochMetrics(now time.Time) *epochMetrics {
+	m := &epochMetrics{
+		firstSeen: now,
+		projects:  make(map[string]struct{}),
+		slots:     make(map[uint64]struct{}),
+	}
+	m.lastSeen.Store(now.UnixNano())
+	return m
+}
+
+// receive counts an incoming submission
+func (m *epochMetrics) receive(request *pkgs.Request) {
+	m.received.Add(1)
+	m.lastSeen.Store(time.Now().UnixNano())
+	if m.projects == nil || request == nil {
+		return
+	}
+	m.mu.Lock()
+	m.projects[request.ProjectId] = struct{}{}
+	m.slots[request.SlotId] = struct{}{}
+	m.mu.Unlock()
+}
+
+// observe records a completed submission; reason is empty on success
+func (m *epochMetrics) observe(latency time.Duration, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.observed++
+	if len(m.latencies) < latencyReservoirSize {
+		m.latencies = append(m.latencies, latency)
+	} else if i := rand.Int63n(int64(m.observed)); i < latencyReservoirSize {
+		m.latencies[i] = latency
+	}
+
+	if reason != "" {
+		if m.failures == nil {
+			m.failures = make(map[string]uint64)
+		}
+		m.failures[reason]++
+	}
+}
+
+// EpochStatus summarises the submissions of one epoch
+type EpochStatus struct {
+	Received     uint64            `json:"received"`
+	Succeeded    uint64            `json:"succeeded"`
+	Failed       uint64            `json:"failed"`
+	LatencyP50Ms float64           `json:"latencyP50Ms"`
+	LatencyP90Ms float64           `json:"latencyP90Ms"`
+	LatencyP99Ms float64           `json:"latencyP99Ms"`
+	Failures     map[string]uint64 `json:"failures,omitempty"`
+	Closed       bool              `json:"closed,omitempty"`
+}
+
+func (m *epochMetrics) status() EpochStatus {
+	status := EpochStatus{
+		Received:  m.received.Load(),
+		Succeeded: m.succeeded.Load(),
+		Failed:    m.failed.Load(),
+	}
+
+	m.mu.Lock()
+	latencies := append([]time.Duration(nil), m.latencies...)
+	if len(m.failures) > 0 {
+		status.Failures = make(map[string]uint64, len(m.failures))
+		for reason, n := range m.failures {
+			status.Failures[reason] = n
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	status.LatencyP50Ms = percentileMs(latencies, 0.50)
+	status.LatencyP90Ms = percentileMs(latencies, 0.90)
+	status.LatencyP99Ms = percentileMs(latencies, 0.99)
+	return status
+}
+
+// percentileMs returns the nearest-rank percentile of sorted latencies
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(sorted[rank]) / float64(time.Millisecond)
+}
+
+// epochTracker keeps metrics for the newest epochs. The window is anchored
+// at the highest epoch seen, so late submissions for older epochs never move
+// it back, unless the epochs at the top of the window stop receiving
+// submissions altogether. Simulation submissions (epoch 0), late ones for
+// epochs that already left the window and unconfirmed jumps far ahead of it
+// are counted separately.
+type epochTracker struct {
+	latest     atomic.Uint64 // Highest non-simulation epoch seen
+	anchorSeen atomic.Int64  // UnixNano of the latest submission within the window
+	now        func() time.Time
+
+	jumpMu        sync.Mutex
+	maxAdvance    uint64        // Largest jump accepted without confirmation
+	confirmations int           // Submissions needed to confirm a larger jump
+	anchorTimeout time.Duration // Zero keeps the anchor until a higher epoch arrives
+	jumpCandidate uint64        // Unconfirmed epoch far above latest
+	jumpSeen      int           // Submissions near jumpCandidate
+
+	mu         sync.RWMutex
+	window     uint64
+	epochs     map[uint64]*epochMetrics
+	simulation *epochMetrics
+	stale      *epochMetrics
+
+	evicted        []closingEpoch      // Left the window before they closed
+	closedProjects map[uint64][]string // Projects of recently closed epochs
+	summaries      []EpochSummary      // Most recent last
+}
+
+func newEpochTracker(window int) *epochTracker {
+	t := &epochTracker{
+		epochs:         make(map[uint64]*epochMetrics),
+		simulation:     &epochMetrics{},
+		stale:          &epochMetrics{},
+		closedProjects: make(map[uint64][]string),
+		now:            time.Now,
+	}
+	t.setWindow(window)
+	t.setJumpGuard(defaultEpochMaxAdvance, defaultEpochJumpConfirmations, defaultEpochAnchorTimeout)
+	return t
+}
+
+// setJumpGuard configures how the tracker treats epoch IDs that may be bogus.
+// A submission more than maxAdvance epochs above the latest only moves the
+// window once confirmations submissions have landed near it. If nothing is
+// submitted within the window for anchorTimeout, the next older submission
+// re-anchors the window, so a far epoch that was confirmed by mistake cannot
+// strand every real epoch behind it until restart.
+func (t *epochTracker) setJumpGuard(maxAdvance, confirmations int, anchorTimeout time.Duration) {
+	if maxAdvance < 1 {
+		maxAdvance = 1
+	}
+	if confirmations < 1 {
+		confirmations = 1
+	}
+	t.jumpMu.Lock()
+	defer t.jumpMu.Unlock()
+	t.maxAdvance = uint64(maxAdvance)
+	t.confirmations = confirmations
+	t.anchorTimeout = anchorTimeout
+}
+
+// setWindow changes how many epochs, counting back from the latest, are kept
+func (t *epochTracker) setWindow(window int) {
+	if window < 1 {
+		window = 1
+	}
+	t.mu.Lock()
+	t.window = uint64(window)
+	t.evict(t.latest.Load())
+	t.mu.Unlock()
+}
+
+// observe records epochID if it is the highest seen and returns the highest.
+// It is called once per submission.
+func (t *epochTracker) observe(epochID uint64) uint64 {
+	now := t.now()
+	for {
+		latest := t.latest.Load()
+		if epochID <= latest {
+			if t.nearLatest(epochID, latest) {
+				t.anchorSeen.Store(now.UnixNano())
+				return latest
+			}
+			if t.anchorExpired(now) && t.reanchor(latest, epochID, now) {
+				return epochID
+			}
+			return t.latest.Load()
+		}
+		if !t.allowAdvance(latest, epochID) {
+			return latest
+		}
+		if t.latest.CompareAndSwap(latest, epochID) {
+			t.anchorSeen.Store(now.UnixNano())
+			return epochID
+		}
+	}
+}
+
+// nearLatest reports whether epochID is within the window ending at latest
+func (t *epochTracker) nearLatest(epochID, latest uint64) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.inWindow(epochID, latest)
+}
+
+// allowAdvance reports whether the window may move up from latest to
+// epochID. A jump beyond maxAdvance is counted and only accepted once enough
+// submissions landed near each other.
+func (t *epochTracker) allowAdvance(latest, epochID uint64) bool {
+	t.jumpMu.Lock()
+	defer t.jumpMu.Unlock()
+
+	if epochID-latest <= t.maxAdvance {
+		return true
+	}
+	near := t.jumpSeen > 0 && absDiff(epochID, t.jumpCandidate) <= t.maxAdvance
+	if !near {
+		t.jumpCandidate = epochID
+		t.jumpSeen = 0
+	}
+	t.jumpSeen++
+	if t.jumpSeen < t.confirmations {
+		grpcLog.Warnf("⚠️ Epoch %d is far above the latest epoch %d; not tracking it until confirmed",
+			epochID, latest)
+		return false
+	}
+	t.jumpSeen = 0
+	return true
+}
+
+// anchorExpired reports whether nothing was submitted within the window for
+// longer than the anchor timeout
+func (t *epochTracker) anchorExpired(now time.Time) bool {
+	t.jumpMu.Lock()
+	timeout := t.anchorTimeout
+	t.jumpMu.Unlock()
+	return timeout > 0 && now.Sub(time.Unix(0, t.anchorSeen.Load())) > timeout
+}
+
+// reanchor moves the window down from latest to epochID, closing the epochs
+// above it. It reports false if another submission moved the window first.
+func (t *epochTracker) reanchor(latest, epochID uint64, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.latest.CompareAndSwap(latest, epochID) {
+		return false
+	}
+	t.anchorSeen.Store(now.UnixNano())
+	for id, m := range t.epochs {
+		if id > epochID {
+			if !m.closed {
+				m.closed = true
+				t.evicted = append(t.evicted, closingEpoch{id, m, epochClosedEvicted})
+			}
+			delete(t.epochs, id)
+		}
+	}
+	t.evict(epochID)
+	grpcLog.Warnf("⚠️ No submissions near epoch %d for a while; re-anchoring the epoch window at %d", latest, epochID)
+	return true
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// latestEpoch returns the highest non-simulation epoch seen
+func (t *epochTracker) latestEpoch() uint64 {
+	return t.latest.Load()
+}
+
+// metricsFor returns the metrics a submission for epochID is counted in
+func (t *epochTracker) metricsFor(epochID uint64) *epochMetrics {
+	if epochID == 0 {
+		return t.simulation
+	}
+	latest := t.observe(epochID)
+	if epochID > latest {
+		// An unconfirmed jump is not tracked until the window reaches it
+		return t.stale
+	}
+
+	t.mu.RLock()
+	m, ok := t.epochs[epochID]
+	outside := !t.inWindow(epochID, latest)
+	t.mu.RUnlock()
+	if ok {
+		return m
+	}
+	if outside {
+		return t.stale
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if m, ok := t.epochs[epochID]; ok {
+		return m
+	}
+	// The window may have moved on while the lock was released
+	latest = t.latest.Load()
+	if !t.inWindow(epochID, latest) {
+		return t.stale
+	}
+	m = newEpochMetrics(time.Now())
+	t.epochs[epochID] = m
+	t.evict(latest)
+	return m
+}
+
+// inWindow reports whether epochID is one of the window epochs ending at
+// latest, without overflowing near either end of the epoch range
+func (t *epochTracker) inWindow(epochID, latest uint64) bool {
+	return epochID > latest || latest-epochID < t.window
+}
+
+// evict drops epochs that fell out of the window, queueing those not closed
+// yet for a summary; callers hold t.mu
+func (t *epochTracker) evict(latest uint64) {
+	for epochID, m := range t.epochs {
+		if !t.inWindow(epochID, latest) {
+			if !m.closed {
+				m.closed = true
+				t.evicted = append(t.evicted, closingEpoch{epochID, m, epochClosedEvicted})
+			}
+			delete(t.epochs, epochID)
+		}
+	}
+}
+
+// EpochReport is the state of the epoch tracker
+type EpochReport struct {
+	Latest     uint64                 `json:"latest"`
+	Epochs     map[uint64]EpochStatus `json:"epochs"`
+	Simulation EpochStatus            `json:"simulation"`
+	Stale      EpochStatus            `json:"stale"`
+	Summaries  []EpochSummary         `json:"summaries,omitempty"` // Recently closed epochs
+}
+
+// report summarises every tracked epoch
+func (t *epochTracker) report() EpochReport {
+	t.mu.RLock()
+	epochs := make(map[uint64]*epochMetrics, len(t.epochs))
+	closed := make(map[uint64]bool)
+	for epochID, m := range t.epochs {
+		epochs[epochID] = m
+		closed[epochID] = m.closed
+	}
+	summaries := append([]EpochSummary(nil), t.summaries...)
+	t.mu.RUnlock()
+
+	report := EpochReport{
+		Latest:     t.latestEpoch(),
+		Epochs:     make(map[uint64]EpochStatus, len(epochs)),
+		Simulation: t.simulation.status(),
+		Stale:      t.stale.status(),
+		Summaries:  summaries,
+	}
+	for epochID, m := range epochs {
+		status := m.status()
+		status.Closed = closed[epochID]
+		report.Epochs[epochID] = status
+	}
+	return report
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEpochTrackerLateEpochsKeepNewerMetrics(t *testing.T) {
+	tracker := newEpochTracker(3)
+
+	tracker.metricsFor(10).received.Add(1)
+	tracker.metricsFor(9).received.Add(1)
+	assert.Equal(t, uint64(10), tracker.latestEpoch())
+
+	report := tracker.report()
+	assert.Equal(t, uint64(1), report.Epochs[10].Received)
+	assert.Equal(t, uint64(1), report.Epochs[9].Received)
+}
+
+func TestEpochTrackerWindowAndBuckets(t *testing.T) {
+	tracker := newEpochTracker(2)
+
+	tracker.metricsFor(0).received.Add(1)
+	tracker.metricsFor(1).received.Add(1)
+	tracker.metricsFor(2).received.Add(1)
+	tracker.metricsFor(3).received.Add(1)
+	tracker.metricsFor(1).received.Add(1)
+
+	report := tracker.report()
+	assert.Equal(t, uint64(3), report.Latest)
+	assert.Len(t, report.Epochs, 2)
+	assert.Contains(t, report.Epochs, uint64(2))
+	assert.Contains(t, report.Epochs, uint64(3))
+	assert.Equal(t, uint64(1), report.Simulation.Received)
+	assert.Equal(t, uint64(1), report.Stale.Received)
+
+	tracker.setWindow(1)
+	assert.Len(t, tracker.report().Epochs, 1)
+}
+
+func TestEpochMetricsPercentilesAndFailures(t *testing.T) {
+	m := &epochMetrics{}
+	for i := 1; i <= 100; i++ {
+		m.observe(time.Duration(i)*time.Millisecond, "")
+	}
+	m.observe(time.Second, "timeout")
+	m.observe(time.Second, "timeout")
+
+	status := m.status()
+	assert.Equal(t, float64(51), status.LatencyP50Ms)
+	assert.Equal(t, float64(92), status.LatencyP90Ms)
+	assert.Equal(t, float64(1000), status.LatencyP99Ms)
+	assert.Equal(t, map[string]uint64{"timeout": 2}, status.Failures)
+}
+
+func TestEpochTrackerIgnoresUnconfirmedJumps(t *testing.T) {
+	tracker := newEpochTracker(3)
+
+	tracker.metricsFor(100).received.Add(1)
+	tracker.metricsFor(math.MaxUint64).received.Add(1)
+	assert.Equal(t, uint64(100), tracker.latestEpoch())
+
+	tracker.metricsFor(101).received.Add(1)
+	report := tracker.report()
+	assert.Equal(t, uint64(101), report.Latest)
+	assert.Equal(t, uint64(1), report.Epochs[101].Received)
+	assert.Equal(t, uint64(1), report.Stale.Received)
+
+	// A real jump, e.g. after a long idle period, is confirmed by the next
+	// submission near it
+	tracker.metricsFor(5000).received.Add(1)
+	tracker.metricsFor(5000).received.Add(1)
+	assert.Equal(t, uint64(5000), tracker.latestEpoch())
+	assert.Equal(t, uint64(1), tracker.report().Epochs[5000].Received)
+}
+
+func TestEpochTrackerRecoversFromPoisonedLatest(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := newEpochTracker(3)
+	tracker.now = func() time.Time { return now }
+	tracker.setJumpGuard(1000, 2, time.Minute)
+
+	tracker.metricsFor(100).received.Add(1)
+
+	// Two submissions for the same bogus epoch pass the jump guard
+	tracker.metricsFor(math.MaxUint64 - 1)
+	tracker.metricsFor(math.MaxUint64 - 1).received.Add(1)
+	assert.Equal(t, uint64(math.MaxUint64-1), tracker.latestEpoch())
+
+	// Real submissions are stale while the bogus epoch still looks current
+	tracker.metricsFor(101).received.Add(1)
+	assert.Equal(t, uint64(1), tracker.report().Stale.Received)
+
+	// Once nothing arrives near the bogus epoch, real traffic re-anchors it
+	now = now.Add(2 * time.Minute)
+	tracker.metricsFor(102).received.Add(1)
+	report := tracker.report()
+	assert.Equal(t, uint64(102), report.Latest)
+	assert.Equal(t, uint64(1), report.Epochs[102].Received)
+	assert.NotContains(t, report.Epochs, uint64(math.MaxUint64-1))
+
+	tracker.metricsFor(103).received.Add(1)
+	assert.Equal(t, uint64(103), tracker.latestEpoch())
+}
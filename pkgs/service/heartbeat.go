@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"strconv"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+// Heartbeat tells the reporting backend the collector is alive, so it can
+// alert when heartbeats stop
+type Heartbeat struct {
+	InstanceID         string                 `json:"instanceID"`
+	Version            string                 `json:"version"`
+	TimeOfReporting    string                 `json:"timeOfReporting"`
+	SequencerID        string                 `json:"sequencerId"`
+	SequencerConnected bool                   `json:"sequencerConnected"`
+	Connectedness      string                 `json:"connectedness"`
+	Paused             bool                   `json:"paused"`
+	CurrentEpoch       uint64                 `json:"currentEpoch"`
+	Epochs             map[uint64]EpochStatus `json:"epochs"`
+}
+
+// StartHeartbeatLoop sends a heartbeat every HeartbeatInterval while
+// heartbeats and reporting are enabled. Both settings are re-read each cycle
+// so config reloads take effect.
+func StartHeartbeatLoop(ctx context.Context, s pkgs.SubmissionServer) {
+	srv, ok := s.(*server)
+	if !ok {
+		reportingLog.Warn("Heartbeats are not supported for the provided server instance")
+		return
+	}
+
+	timer := time.NewTimer(config.Current().HeartbeatInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(config.Current().HeartbeatInterval)
+			reporter := reportingInstance.Load()
+			if !config.Current().HeartbeatEnabled || reporter == nil {
+				continue
+			}
+			// A missed heartbeat is not retried; the next one supersedes it
+			sendCtx, cancel := context.WithTimeout(ctx, config.Current().HeartbeatInterval)
+			if err := reporter.postJSON(sendCtx, reporter.heartbeatURL, srv.heartbeat()); err != nil {
+				reportingLog.Warnf("⚠️ Failed to send heartbeat: %v", err)
+			} else {
+				reportingLog.Debug("💓 Heartbeat sent")
+			}
+			cancel()
+		}
+	}
+}
+
+func (s *server) heartbeat() Heartbeat {
+	sequencer := sequencerStatus()
+	epochs := s.epochs.report()
+	return Heartbeat{
+		InstanceID:         config.Current().SignerAccountAddress,
+		Version:            config.Version,
+		TimeOfReporting:    strconv.FormatInt(time.Now().Unix(), 10),
+		SequencerID:        sequencer.PeerID,
+		SequencerConnected: sequencer.Connectedness == network.Connected.String(),
+		Connectedness:      sequencer.Connectedness,
+		Paused:             s.Paused(),
+		CurrentEpoch:       epochs.Latest,
+		Epochs:             epochs.Epochs,
+	}
+}
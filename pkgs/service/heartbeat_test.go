@@ -0,0 +1,27 @@
+package service
+
+import (
+	"proto-snapshot-server/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatCarriesEpochCounts(t *testing.T) {
+	useSettings(t, &config.Settings{SignerAccountAddress: "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"})
+	defer func(version string) { config.Version = version }(config.Version)
+	config.Version = "v1.2.3"
+	s := &server{epochs: newEpochTracker(4)}
+
+	m := s.epochs.metricsFor(7)
+	m.received.Add(3)
+	m.succeeded.Add(2)
+	m.failed.Add(1)
+
+	hb := s.heartbeat()
+	assert.Equal(t, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", hb.InstanceID)
+	assert.Equal(t, "v1.2.3", hb.Version)
+	assert.Equal(t, uint64(7), hb.CurrentEpoch)
+	assert.False(t, hb.SequencerConnected)
+	assert.Equal(t, EpochStatus{Received: 3, Succeeded: 2, Failed: 1}, hb.Epochs[7])
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeouts of the admin and metrics HTTP servers
+const (
+	httpReadHeaderTimeout = 5 * time.Second
+	httpReadTimeout       = 10 * time.Second
+	httpWriteTimeout      = 2 * time.Minute
+	httpIdleTimeout       = 120 * time.Second
+	httpShutdownTimeout   = 5 * time.Second
+
+	// Longest /admin/drain wait, leaving time to write the response
+	maxDrainTimeout = httpWriteTimeout - 10*time.Second
+)
+
+var (
+	httpServersMu     sync.Mutex
+	httpServers       []*http.Server
+	httpServersClosed bool
+)
+
+// serveHTTP serves handler on addr until shutdownHTTPServers is called
+func serveHTTP(addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: httpReadHeaderTimeout,
+		ReadTimeout:       httpReadTimeout,
+		WriteTimeout:      httpWriteTimeout,
+		IdleTimeout:       httpIdleTimeout,
+	}
+
+	httpServersMu.Lock()
+	if httpServersClosed {
+		httpServersMu.Unlock()
+		return http.ErrServerClosed
+	}
+	httpServers = append(httpServers, srv)
+	httpServersMu.Unlock()
+
+	return srv.ListenAndServe()
+}
+
+// shutdownHTTPServers stops the servers started by serveHTTP, letting
+// in-flight requests finish within httpShutdownTimeout
+func shutdownHTTPServers() {
+	httpServersMu.Lock()
+	servers := httpServers
+	httpServers, httpServersClosed = nil, true
+	httpServersMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			adminLog.Warnf("HTTP server on %s did not shut down cleanly: %v", srv.Addr, err)
+		}
+	}
+}
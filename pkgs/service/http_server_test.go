@@ -0,0 +1,39 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownHTTPServersStopsServing(t *testing.T) {
+	t.Cleanup(func() {
+		httpServersMu.Lock()
+		httpServers, httpServersClosed = nil, false
+		httpServersMu.Unlock()
+	})
+
+	served := make(chan error, 1)
+	go func() { served <- serveHTTP("127.0.0.1:0", http.NotFoundHandler()) }()
+	assert.Eventually(t, func() bool {
+		httpServersMu.Lock()
+		defer httpServersMu.Unlock()
+		return len(httpServers) == 1
+	}, time.Second, 10*time.Millisecond)
+	httpServersMu.Lock()
+	assert.Equal(t, httpReadHeaderTimeout, httpServers[0].ReadHeaderTimeout)
+	httpServersMu.Unlock()
+
+	shutdownHTTPServers()
+	select {
+	case err := <-served:
+		assert.ErrorIs(t, err, http.ErrServerClosed)
+	case <-time.After(time.Second):
+		t.Fatal("server kept serving after shutdown")
+	}
+
+	// Servers started late do not outlive the shutdown
+	assert.ErrorIs(t, serveHTTP("127.0.0.1:0", http.NotFoundHandler()), http.ErrServerClosed)
+}
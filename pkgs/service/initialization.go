@@ -7,7 +7,6 @@ import (
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
 
@@ -29,7 +28,7 @@ func InitializeService() error {
 	defer deps.mu.Unlock()
 
 	if deps.initialized {
-		log.Warn("Service already initialized")
+		connLog.Warn("Service already initialized")
 		return nil
 	}
 
@@ -51,13 +50,13 @@ func InitializeService() error {
 	deps.sequencerID = SequencerID
 
 	// Initialize stream pool
-	if err := InitLibp2pStreamPool(config.SettingsObj.MaxStreamPoolSize); err != nil {
+	if err := InitLibp2pStreamPool(config.Current().MaxStreamPoolSize); err != nil {
 		return fmt.Errorf("failed to initialize stream pool: %w", err)
 	}
 
 	deps.streamPool = GetLibp2pStreamPool()
 	deps.initialized = true
 
-	log.Info("Service initialization complete with sequencer ID: ", deps.sequencerID.String())
+	connLog.Info("Service initialization complete with sequencer ID: ", deps.sequencerID.String())
 	return nil
 }
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Ledger outcomes
+const (
+	LedgerSucceeded = "succeeded"
+	LedgerFailed    = "failed"
+)
+
+// LedgerRecord is what the collector did with one submission
+type LedgerRecord struct {
+	SubmissionID string    `json:"submissionId"`
+	EpochID      uint64    `json:"epochId"`
+	ProjectID    string    `json:"projectId"`
+	SlotID       uint64    `json:"slotId"`
+	SnapshotCID  string    `json:"snapshotCid"`
+	ReceivedAt   time.Time `json:"receivedAt"`
+	CompletedAt  time.Time `json:"completedAt"`
+	Outcome      string    `json:"outcome"`
+	Reason       string    `json:"reason,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// LedgerQuery selects ledger records. Zero values match everything.
+type LedgerQuery struct {
+	EpochID   *uint64
+	SlotID    *uint64
+	ProjectID string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// Records are keyed by receive time then submission ID, so retention and
+// time range queries are key range scans. The epoch index maps epoch + record
+// key to nothing.
+var (
+	ledgerRecordsBucket = []byte("submissions")
+	ledgerEpochBucket   = []byte("by_epoch")
+)
+
+const (
+	defaultLedgerQueryLimit = 1000
+	ledgerFlushInterval     = 200 * time.Millisecond
+	ledgerMaxBatch          = 1000
+)
+
+// SubmissionLedger persists a record of every submission the collector
+// handled. Records are written in batches from a background goroutine so the
+// submission path never waits on disk.
+type SubmissionLedger struct {
+	db        *bolt.DB
+	retention time.Duration
+	pending   chan LedgerRecord
+
+	stop     chan struct{}
+	done     sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// ledger holds the collector's ledger; nil when disabled
+var ledger atomic.Pointer[SubmissionLedger]
+
+// InitLedger opens the ledger at path and starts writing and pruning it
+func InitLedger(path string, retention time.Duration, queueSize int) error {
+	l, err := OpenLedger(path, false)
+	if err != nil {
+		return err
+	}
+	l.retention = retention
+	l.pending = make(chan LedgerRecord, queueSize)
+	l.stop = make(chan struct{})
+	l.done.Add(2)
+	go l.writeLoop()
+	go l.pruneLoop()
+	ledger.Store(l)
+	ledgerLog.Infof("📒 Submission ledger at %s (retention %v)", path, retention)
+	return nil
+}
+
+// CloseLedger flushes pending records and closes the collector's ledger
+func CloseLedger() {
+	if l := ledger.Swap(nil); l != nil {
+		if err := l.Close(); err != nil {
+			ledgerLog.Warnf("Failed to close ledger: %v", err)
+		}
+	}
+}
+
+// OpenLedger opens the ledger database at path. A read-only ledger only
+// serves queries; it cannot be opened while the collector holds the file.
+func OpenLedger(path string, readOnly bool) (*SubmissionLedger, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger %s: %w", path, err)
+	}
+	if !readOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{ledgerRecordsBucket, ledgerEpochBucket} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialise ledger: %w", err)
+		}
+	}
+	return &SubmissionLedger{db: db}, nil
+}
+
+// Close stops background work, writing any pending records, and closes the
+// database
+func (l *SubmissionLedger) Close() error {
+	if l.stop != nil {
+		l.stopOnce.Do(func() { close(l.stop) })
+		l.done.Wait()
+	}
+	return l.db.Close()
+}
+
+// record queues r for writing. Records are dropped, with a warning, when the
+// writer falls behind.
+func (l *SubmissionLedger) record(r LedgerRecord) {
+	if l == nil {
+		return
+	}
+	select {
+	case l.pending <- r:
+	default:
+		ledgerLog.Warnf("🚫 Ledger queue full, dropped record for submission %s", r.SubmissionID)
+	}
+}
+
+func (l *SubmissionLedger) writeLoop() {
+	defer l.done.Done()
+	ticker := time.NewTicker(ledgerFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]LedgerRecord, 0, ledgerMaxBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.write(batch); err != nil {
+			ledgerLog.Errorf("❌ Failed to write %d ledger records: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case r := <-l.pending:
+			batch = append(batch, r)
+			if len(batch) >= ledgerMaxBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-l.stop:
+			for {
+				select {
+				case r := <-l.pending:
+					batch = append(batch, r)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write stores records in one transaction
+func (l *SubmissionLedger) write(records []LedgerRecord) error {
+	return l.db.Update(func(tx *bolt.Tx) error {
+		recordsBucket, epochBucket := tx.Bucket(ledgerRecordsBucket), tx.Bucket(ledgerEpochBucket)
+		for _, r := range records {
+			value, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			key := recordKey(r.ReceivedAt, r.SubmissionID)
+			if err := recordsBucket.Put(key, value); err != nil {
+				return err
+			}
+			if err := epochBucket.Put(append(uint64Key(r.EpochID), key...), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (l *SubmissionLedger) pruneLoop() {
+	defer l.done.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if removed, err := l.Prune(time.Now().Add(-l.retention)); err != nil {
+			ledgerLog.Errorf("❌ Failed to prune ledger: %v", err)
+		} else if removed > 0 {
+			ledgerLog.Infof("🧹 Pruned %d ledger records older than %v", removed, l.retention)
+		}
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Prune deletes records received before cutoff and returns how many it removed
+func (l *SubmissionLedger) Prune(cutoff time.Time) (int, error) {
+	removed := 0
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		recordsBucket, epochBucket := tx.Bucket(ledgerRecordsBucket), tx.Bucket(ledgerEpochBucket)
+		end := uint64Key(uint64(cutoff.UnixNano()))
+
+		c := recordsBucket.Cursor()
+		for k, v := c.First(); k != nil && bytes.Compare(k[:8], end) < 0; k, v = c.First() {
+			var r LedgerRecord
+			if err := json.Unmarshal(v, &r); err == nil {
+				if err := epochBucket.Delete(append(uint64Key(r.EpochID), k...)); err != nil {
+					return err
+				}
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Query returns the records matching q, oldest first
+func (l *SubmissionLedger) Query(q LedgerQuery) ([]LedgerRecord, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLedgerQueryLimit
+	}
+
+	records := []LedgerRecord{}
+	err := l.db.View(func(tx *bolt.Tx) error {
+		recordsBucket, epochBucket := tx.Bucket(ledgerRecordsBucket), tx.Bucket(ledgerEpochBucket)
+		if recordsBucket == nil || epochBucket == nil {
+			return nil
+		}
+
+		visit := func(value []byte) bool {
+			var r LedgerRecord
+			if err := json.Unmarshal(value, &r); err != nil || !q.matches(r) {
+				return true
+			}
+			records = append(records, r)
+			return len(records) < limit
+		}
+
+		// An epoch query walks the epoch index, anything else the time range
+		if q.EpochID != nil {
+			prefix := uint64Key(*q.EpochID)
+			c := epochBucket.Cursor()
+			for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+				if value := recordsBucket.Get(k[8:]); value != nil && !visit(value) {
+					break
+				}
+			}
+			return nil
+		}
+
+		c := recordsBucket.Cursor()
+		k, v := c.First()
+		if !q.Since.IsZero() {
+			k, v = c.Seek(uint64Key(uint64(q.Since.UnixNano())))
+		}
+		for ; k != nil; k, v = c.Next() {
+			if !q.Until.IsZero() && bytes.Compare(k[:8], uint64Key(uint64(q.Until.UnixNano()))) > 0 {
+				break
+			}
+			if !visit(v) {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func (q LedgerQuery) matches(r LedgerRecord) bool {
+	switch {
+	case q.EpochID != nil && r.EpochID != *q.EpochID:
+		return false
+	case q.SlotID != nil && r.SlotID != *q.SlotID:
+		return false
+	case q.ProjectID != "" && r.ProjectID != q.ProjectID:
+		return false
+	case q.Outcome != "" && r.Outcome != q.Outcome:
+		return false
+	case !q.Since.IsZero() && r.ReceivedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && r.ReceivedAt.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// ParseLedgerQuery reads a query from URL parameters: epoch, slot, project,
+// outcome, limit, and since/until as RFC 3339 times or durations before now
+// such as 1h
+func ParseLedgerQuery(values url.Values) (LedgerQuery, error) {
+	var q LedgerQuery
+	for name, target := range map[string]**uint64{"epoch": &q.EpochID, "slot": &q.SlotID} {
+		if raw := values.Get(name); raw != "" {
+			n, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an unsigned integer", name)
+			}
+			*target = &n
+		}
+	}
+	q.ProjectID = values.Get("project")
+	q.Outcome = values.Get("outcome")
+	if q.Outcome != "" && q.Outcome != LedgerSucceeded && q.Outcome != LedgerFailed {
+		return q, fmt.Errorf("outcome must be %s or %s", LedgerSucceeded, LedgerFailed)
+	}
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			return q, fmt.Errorf("limit must be a non-negative integer")
+		}
+		q.Limit = limit
+	}
+	for name, target := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if raw := values.Get(name); raw != "" {
+			t, err := parseLedgerTime(raw)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 time or a duration such as 1h", name)
+			}
+			*target = t
+		}
+	}
+	return q, nil
+}
+
+func parseLedgerTime(raw string) (time.Time, error) {
+	if d, err := time.ParseDuration(raw); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+func recordKey(receivedAt time.Time, submissionID string) []byte {
+	return append(uint64Key(uint64(receivedAt.UnixNano())), submissionID...)
+}
+
+func uint64Key(n uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, n)
+	return key
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerQueriesAndPrunes(t *testing.T) {
+	l, err := OpenLedger(filepath.Join(t.TempDir(), "ledger.db"), false)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	now := time.Now()
+	records := []LedgerRecord{
+		{SubmissionID: "old", EpochID: 9, SlotID: 1, ReceivedAt: now.Add(-3 * time.Hour), Outcome: LedgerSucceeded},
+		{SubmissionID: "a", EpochID: 10, SlotID: 1, ReceivedAt: now.Add(-30 * time.Minute), Outcome: LedgerSucceeded},
+		{SubmissionID: "b", EpochID: 10, SlotID: 2, ReceivedAt: now.Add(-20 * time.Minute), Outcome: LedgerFailed, Error: "write failed"},
+		{SubmissionID: "c", EpochID: 11, SlotID: 1, ReceivedAt: now.Add(-10 * time.Minute), Outcome: LedgerFailed},
+	}
+	assert.NoError(t, l.write(records))
+
+	ids := func(q LedgerQuery) []string {
+		found, err := l.Query(q)
+		assert.NoError(t, err)
+		var result []string
+		for _, r := range found {
+			result = append(result, r.SubmissionID)
+		}
+		return result
+	}
+
+	epoch, slot := uint64(10), uint64(1)
+	assert.Equal(t, []string{"b"}, ids(LedgerQuery{EpochID: &epoch, Outcome: LedgerFailed}))
+	assert.Equal(t, []string{"a", "c"}, ids(LedgerQuery{SlotID: &slot, Since: now.Add(-time.Hour)}))
+	assert.Equal(t, []string{"old", "a"}, ids(LedgerQuery{Limit: 2}))
+
+	removed, err := l.Prune(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	oldEpoch := uint64(9)
+	assert.Empty(t, ids(LedgerQuery{EpochID: &oldEpoch}))
+	assert.Equal(t, []string{"a", "b", "c"}, ids(LedgerQuery{}))
+}
+
+func TestParseLedgerQuery(t *testing.T) {
+	q, err := ParseLedgerQuery(url.Values{"epoch": {"42"}, "outcome": {"failed"}, "since": {"1h"}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), *q.EpochID)
+	assert.Nil(t, q.SlotID)
+	assert.Equal(t, LedgerFailed, q.Outcome)
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), q.Since, time.Minute)
+
+	for _, bad := range []url.Values{{"slot": {"-1"}}, {"outcome": {"lost"}}, {"until": {"yesterday"}}} {
+		_, err := ParseLedgerQuery(bad)
+		assert.Error(t, err)
+	}
+}
+
+func TestLedgerCanCloseWhileRecording(t *testing.T) {
+	assert.NoError(t, InitLedger(filepath.Join(t.TempDir(), "ledger.db"), time.Hour, 100))
+	t.Cleanup(CloseLedger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ledger.Load().record(LedgerRecord{SubmissionID: fmt.Sprintf("%d-%d", i, j)})
+			}
+		}(i)
+	}
+	CloseLedger()
+	wg.Wait()
+	assert.Nil(t, ledger.Load())
+}
@@ -3,16 +3,31 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"proto-snapshot-server/config"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
-	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Stream strategies selectable through STREAM_STRATEGY
+const (
+	StreamStrategyPooled    = "pooled"
+	StreamStrategyEphemeral = "ephemeral"
+)
+
+// CollectProtocol is the libp2p protocol submissions are written on
+const CollectProtocol = "/collect"
+
+// Upper bound on a sequencer response read from an ephemeral stream
+const maxEphemeralResponseBytes = 4096
+
 // Global variables for service-wide access
 var (
 	libp2pStreamPool   *StreamPool
@@ -24,9 +39,10 @@ type StreamPool struct {
 	mu          sync.Mutex
 	streams     []network.Stream
 	maxSize     int
-	sequencerID peer.ID
+	sequencerID atomic.Value   // peer.ID streams are opened to; changes on reconnect
 	reqQueue    chan *reqSlot  // For stream acquisition with identifiers
 	activeOps   sync.WaitGroup // Track active operations
+	ephemeral   bool           // Streams carry one frame each; the pool only keeps them warm
 }
 
 // streamWithSlot bundles a stream with its request slot
@@ -41,19 +57,26 @@ type reqSlot struct {
 	createdAt time.Time
 }
 
+// sequencer returns the peer ID of the sequencer the pool streams to
+func (p *StreamPool) sequencer() peer.ID {
+	id, _ := p.sequencerID.Load().(peer.ID)
+	return id
+}
+
 // createStream is now a method of StreamPool
-func (p *StreamPool) createStream() (network.Stream, error) {
+func (p *StreamPool) createStream(ctx context.Context) (network.Stream, error) {
 	if SequencerHostConn == nil {
 		return nil, fmt.Errorf("no sequencer connection available")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), config.SettingsObj.StreamWriteTimeout)
+	ctx, cancel := context.WithTimeout(ctx, config.Current().StreamWriteTimeout)
 	defer cancel()
 
-	stream, err := SequencerHostConn.NewStream(ctx, p.sequencerID, "/collect")
+	stream, err := SequencerHostConn.NewStream(ctx, p.sequencer(), CollectProtocol)
 	if err != nil {
 		return nil, fmt.Errorf("new stream creation failed: %w", err)
 	}
+	streamsCreated.Inc()
 
 	return stream, nil
 }
@@ -68,26 +91,34 @@ func InitLibp2pStreamPool(maxSize int) error {
 		return fmt.Errorf("cannot initialize pool: %w", err)
 	}
 
+	switch config.Current().StreamStrategy {
+	case StreamStrategyPooled, StreamStrategyEphemeral:
+	default:
+		poolLog.Warnf("Unknown stream strategy %q, falling back to %s", config.Current().StreamStrategy, StreamStrategyPooled)
+	}
+
 	pool := &StreamPool{
-		streams:     make([]network.Stream, 0, maxSize),
-		maxSize:     maxSize,
-		sequencerID: seqId,
-		reqQueue:    make(chan *reqSlot, config.SettingsObj.MaxStreamQueueSize),
+		streams:   make([]network.Stream, 0, maxSize),
+		maxSize:   maxSize,
+		reqQueue:  make(chan *reqSlot, config.Current().MaxStreamQueueSize),
+		ephemeral: config.Current().StreamStrategy == StreamStrategyEphemeral,
 	}
 
+	pool.sequencerID.Store(seqId)
+
 	// Pre-fill the pool with streams
 	for i := 0; i < maxSize; i++ {
-		stream, err := pool.createNewStreamWithRetry()
+		stream, err := pool.createNewStreamWithRetry(context.Background())
 		if err != nil {
-			log.Errorf("Failed to create stream %d/%d: %v", i+1, maxSize, err)
+			poolLog.Errorf("Failed to create stream %d/%d: %v", i+1, maxSize, err)
 			continue
 		}
 		pool.streams = append(pool.streams, stream)
 	}
 
 	libp2pStreamPool = pool
-	log.Infof("Stream pool initialized with %d/%d streams for sequencer: %s",
-		len(pool.streams), maxSize, seqId.String())
+	poolLog.Infof("Stream pool initialized with %d/%d streams for sequencer: %s (strategy: %s)",
+		len(pool.streams), maxSize, seqId.String(), pool.strategy())
 	return nil
 }
 
@@ -96,14 +127,16 @@ func GetLibp2pStreamPool() *StreamPool {
 	defer libp2pStreamPoolMu.RUnlock()
 
 	if libp2pStreamPool == nil {
-		log.Warn("Attempted to access uninitialized stream pool")
+		poolLog.Warn("Attempted to access uninitialized stream pool")
 		return nil
 	}
 	return libp2pStreamPool
 }
 
-func (p *StreamPool) GetStream() (*streamWithSlot, error) {
-	log.Debug("🎯 Attempting to acquire stream")
+// GetStream acquires a request queue slot and a healthy stream. It gives up,
+// releasing the slot, as soon as ctx is done.
+func (p *StreamPool) GetStream(ctx context.Context) (*streamWithSlot, error) {
+	poolLog.Debug("🎯 Attempting to acquire stream")
 
 	// Create a new request slot with identifier
 	slot := &reqSlot{
@@ -114,30 +147,35 @@ func (p *StreamPool) GetStream() (*streamWithSlot, error) {
 	// First check if we can queue the request
 	select {
 	case p.reqQueue <- slot:
-		log.Debugf("✅ Acquired request queue slot [%s]", slot.id)
+		poolLog.Debugf("✅ Acquired request queue slot [%s]", slot.id)
 	default:
-		log.Warn("🚫 Request queue full - backpressure applied")
-		return nil, fmt.Errorf("request queue full - try again later")
+		poolLog.Warn("🚫 Request queue full - backpressure applied")
+		err := fmt.Errorf("request queue full - try again later")
+		reportIssue(IssueStreamPoolExhausted, nil, err, IssueDetails{
+			"queueCapacity": cap(p.reqQueue),
+			"sequencerId":   p.sequencer().String(),
+		})
+		return nil, err
 	}
 
-	log.Debug("👥 Tracking active operation")
+	poolLog.Debug("👥 Tracking active operation")
 	p.activeOps.Add(1)
 	defer func() {
 		p.activeOps.Done()
-		log.Debug("👋 Operation completed and untracked")
+		poolLog.Debug("👋 Operation completed and untracked")
 	}()
 
 	// Now wait for refresh to complete if needed
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = 30 * time.Second
-	b.InitialInterval = 100 * time.Millisecond
+	b := newContextBackOff(ctx, 100*time.Millisecond)
 
 	var stream network.Stream
 	attempt := 0
 	err := backoff.Retry(func() error {
 		attempt++
 		if connectionRefreshing.Load() {
-			log.Debugf("⏳ Stream acquisition waiting for refresh (attempt %d) [slot: %s]", attempt, slot.id)
+			poolLog.Debugf("⏳ Stream acquisition waiting for refresh (attempt %d) [slot: %s]", attempt, slot.id)
+			trace.SpanFromContext(ctx).AddEvent("waiting for connection refresh",
+				trace.WithAttributes(attribute.Int("attempt", attempt)))
 			return fmt.Errorf("connection refresh in progress")
 		}
 
@@ -147,44 +185,46 @@ func (p *StreamPool) GetStream() (*streamWithSlot, error) {
 		if len(p.streams) > 0 {
 			stream = p.streams[len(p.streams)-1]
 			p.streams = p.streams[:len(p.streams)-1]
-			log.Debugf("🔍 Retrieved stream from pool, verifying... [slot: %s, stream: %v]", slot.id, stream.ID())
+			poolLog.Debugf("🔍 Retrieved stream from pool, verifying... [slot: %s, stream: %v]", slot.id, stream.ID())
 
 			if stream.Conn() == nil || stream.Conn().IsClosed() {
-				log.Debugf("⚠️ Found stale stream, closing [slot: %s, stream: %v]", slot.id, stream.ID())
+				poolLog.Debugf("⚠️ Found stale stream, closing [slot: %s, stream: %v]", slot.id, stream.ID())
 				stream.Close()
+				streamsDiscarded.WithLabelValues("stale").Inc()
 				return fmt.Errorf("stale stream detected")
 			}
 
 			if err := p.pingStream(stream); err != nil {
-				log.Debugf("💔 Stream health check failed, closing [slot: %s, stream: %v]", slot.id, stream.ID())
+				poolLog.Debugf("💔 Stream health check failed, closing [slot: %s, stream: %v]", slot.id, stream.ID())
 				stream.Close()
+				streamsDiscarded.WithLabelValues("unhealthy").Inc()
 				return fmt.Errorf("stream health check failed: %v", err)
 			}
 
-			log.Debugf("✨ Retrieved healthy stream from pool [slot: %s, stream: %v]", slot.id, stream.ID())
+			poolLog.Debugf("✨ Retrieved healthy stream from pool [slot: %s, stream: %v]", slot.id, stream.ID())
 			return nil
 		}
 
-		log.Debugf("🏗️ Creating new stream [slot: %s]", slot.id)
-		newStream, err := p.createNewStreamWithRetry()
+		poolLog.Debugf("🏗️ Creating new stream [slot: %s]", slot.id)
+		newStream, err := p.createNewStreamWithRetry(ctx)
 		if err != nil {
-			log.Debugf("❌ Failed to create new stream: %v [slot: %s]", err, slot.id)
+			poolLog.Debugf("❌ Failed to create new stream: %v [slot: %s]", err, slot.id)
 			return fmt.Errorf("failed to create new stream: %v", err)
 		}
 		stream = newStream
-		log.Debugf("✅ Created new stream successfully [slot: %s, stream: %v]", slot.id, stream.ID())
+		poolLog.Debugf("✅ Created new stream successfully [slot: %s, stream: %v]", slot.id, stream.ID())
 		return nil
 	}, b)
 
 	if err != nil {
 		// Release the request queue slot on error
 		<-p.reqQueue
-		log.Debugf("♻️ Released request queue slot due to error [slot: %s, duration: %v]", slot.id, time.Since(slot.createdAt))
-		log.Errorf("❌ Stream acquisition failed after %d attempts: %v [slot: %s]", attempt, err, slot.id)
+		poolLog.Debugf("♻️ Released request queue slot due to error [slot: %s, duration: %v]", slot.id, time.Since(slot.createdAt))
+		poolLog.Errorf("❌ Stream acquisition failed after %d attempts: %v [slot: %s]", attempt, err, slot.id)
 		return nil, fmt.Errorf("failed to acquire stream after retries: %w", err)
 	}
 
-	log.Debugf("🎉 Successfully acquired stream [slot: %s, stream: %v]", slot.id, stream.ID())
+	poolLog.Debugf("🎉 Successfully acquired stream [slot: %s, stream: %v]", slot.id, stream.ID())
 	return &streamWithSlot{stream: stream, slot: slot}, nil
 }
 
@@ -199,17 +239,19 @@ func (p *StreamPool) ReleaseStream(sw *streamWithSlot, failed bool) {
 		if sw.stream != nil {
 			sw.stream.Reset()
 			sw.stream.Close()
+			streamsDiscarded.WithLabelValues("failed").Inc()
 		}
-	} else {
+	} else if sw.stream != nil {
 		// On success, return stream to pool
 		p.mu.Lock()
 		if len(p.streams) >= p.maxSize {
 			// Pool full, gracefully close the stream
 			sw.stream.Close()
-			log.Debugf("Stream gracefully closed as pool is full: %v", sw.stream.ID())
+			streamsDiscarded.WithLabelValues("pool_full").Inc()
+			poolLog.Debugf("Stream gracefully closed as pool is full: %v", sw.stream.ID())
 		} else {
 			p.streams = append(p.streams, sw.stream)
-			log.Debugf("Stream returned to pool: %v (pool size: %d/%d)", sw.stream.ID(), len(p.streams), p.maxSize)
+			poolLog.Debugf("Stream returned to pool: %v (pool size: %d/%d)", sw.stream.ID(), len(p.streams), p.maxSize)
 		}
 		p.mu.Unlock()
 	}
@@ -217,48 +259,172 @@ func (p *StreamPool) ReleaseStream(sw *streamWithSlot, failed bool) {
 	// Always release the slot
 	if sw.slot != nil {
 		<-p.reqQueue
-		log.Debugf("♻️ Released request queue slot [slot: %s, duration: %v]", sw.slot.id, time.Since(sw.slot.createdAt))
+		poolLog.Debugf("♻️ Released request queue slot [slot: %s, duration: %v]", sw.slot.id, time.Since(sw.slot.createdAt))
+	}
+}
+
+// CompleteWrite hands back a stream after a successful write. Pooled streams
+// return to the pool; ephemeral streams are half-closed, optionally drained
+// for a sequencer response, closed and replaced with a fresh warm stream.
+func (p *StreamPool) CompleteWrite(sw *streamWithSlot) {
+	if !p.ephemeral {
+		p.ReleaseStream(sw, false)
+		return
+	}
+
+	stream := sw.stream
+	if err := stream.CloseWrite(); err != nil {
+		poolLog.Warnf("⚠️ Failed to half-close ephemeral stream %s: %v", stream.ID(), err)
+		p.ReleaseStream(&streamWithSlot{stream: stream, slot: sw.slot}, true)
+		go p.replenish()
+		return
+	}
+
+	if config.Current().EphemeralReadResponse {
+		p.readResponse(stream)
+	}
+
+	if err := stream.Close(); err != nil {
+		poolLog.Debugf("Error closing ephemeral stream %s: %v", stream.ID(), err)
+	}
+	p.ReleaseStream(&streamWithSlot{slot: sw.slot}, false)
+	go p.replenish()
+}
+
+// readResponse logs what the sequencer sent back on a half-closed ephemeral
+// stream. Without a read deadline the read could block forever, so it is
+// skipped when the deadline cannot be set.
+func (p *StreamPool) readResponse(stream network.Stream) {
+	if err := stream.SetReadDeadline(time.Now().Add(config.Current().EphemeralResponseTimeout)); err != nil {
+		poolLog.Warnf("⚠️ Not reading sequencer response on stream %s: failed to set read deadline: %v", stream.ID(), err)
+		return
+	}
+	response, err := io.ReadAll(io.LimitReader(stream, maxEphemeralResponseBytes))
+	if err != nil {
+		poolLog.Warnf("⚠️ Failed to read sequencer response on stream %s: %v", stream.ID(), err)
+		return
 	}
+	poolLog.Debugf("📨 Sequencer response on stream %s: %q", stream.ID(), response)
+}
+
+// replenish tops up the warm set of ephemeral streams by one
+func (p *StreamPool) replenish() {
+	p.mu.Lock()
+	full := len(p.streams) >= p.maxSize
+	p.mu.Unlock()
+	if full || connectionRefreshing.Load() {
+		return
+	}
+
+	stream, err := p.createNewStreamWithRetry(context.Background())
+	if err != nil {
+		poolLog.Debugf("Failed to replenish ephemeral stream: %v", err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.streams) >= p.maxSize {
+		stream.Close()
+		return
+	}
+	p.streams = append(p.streams, stream)
+}
+
+// Resize changes how many idle streams the pool keeps, closing any surplus
+func (p *StreamPool) Resize(maxSize int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.maxSize = maxSize
+	for len(p.streams) > maxSize {
+		stream := p.streams[len(p.streams)-1]
+		p.streams = p.streams[:len(p.streams)-1]
+		stream.Close()
+		streamsDiscarded.WithLabelValues("pool_full").Inc()
+	}
+	poolLog.Infof("📐 Stream pool resized to %d (idle: %d)", maxSize, len(p.streams))
+}
+
+// StreamPoolStats is a point-in-time view of the stream pool
+type StreamPoolStats struct {
+	Strategy       string `json:"strategy"`
+	Idle           int    `json:"idle"`
+	MaxSize        int    `json:"maxSize"`
+	QueuedRequests int    `json:"queuedRequests"`
+	QueueCapacity  int    `json:"queueCapacity"`
+}
+
+// poolStats reports on the current pool, or zeroes if there is none
+func poolStats() StreamPoolStats {
+	libp2pStreamPoolMu.RLock()
+	p := libp2pStreamPool
+	libp2pStreamPoolMu.RUnlock()
+	if p == nil {
+		return StreamPoolStats{}
+	}
+	return p.Stats()
+}
+
+func (p *StreamPool) Stats() StreamPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return StreamPoolStats{
+		Strategy:       p.strategy(),
+		Idle:           len(p.streams),
+		MaxSize:        p.maxSize,
+		QueuedRequests: len(p.reqQueue),
+		QueueCapacity:  cap(p.reqQueue),
+	}
+}
+
+func (p *StreamPool) strategy() string {
+	if p.ephemeral {
+		return StreamStrategyEphemeral
+	}
+	return StreamStrategyPooled
 }
 
 func (p *StreamPool) pingStream(stream network.Stream) error {
-	timeout := config.SettingsObj.StreamHealthCheckTimeout
+	timeout := config.Current().StreamHealthCheckTimeout
 	if timeout == 0 {
 		timeout = 2 * time.Second // fallback default
 	}
 
 	if err := stream.SetDeadline(time.Now().Add(timeout)); err != nil {
-		log.Debugf("Failed to set stream deadline: %v", err)
+		poolLog.Debugf("Failed to set stream deadline: %v", err)
 		return fmt.Errorf("failed to set deadline: %w", err)
 	}
 	defer stream.SetDeadline(time.Time{}) // Clear deadline
 
 	// Simply check if the connection is closed
 	if stream.Conn() == nil || stream.Conn().IsClosed() {
-		log.Debug("Stream failed health check - connection not alive")
+		poolLog.Debug("Stream failed health check - connection not alive")
 		return fmt.Errorf("stream is not alive")
 	}
 
 	return nil
 }
 
-func (p *StreamPool) createNewStreamWithRetry() (network.Stream, error) {
-	var stream network.Stream
+func (p *StreamPool) createNewStreamWithRetry(ctx context.Context) (stream network.Stream, err error) {
+	ctx, span := tracer.Start(ctx, "create_stream")
+	defer func() { endSpan(span, err) }()
 
 	operation := func() error {
 		// Get current connection state
 		hostConn, seqId, err := GetSequencerConnection()
 		if err != nil {
-			log.Warnf("Connection to sequencer lost, will retry: %v", err)
+			poolLog.Warnf("Connection to sequencer lost, will retry: %v", err)
 			return fmt.Errorf("sequencer connection lost: %w", err)
 		}
 
 		if hostConn.Network().Connectedness(seqId) != network.Connected {
-			log.Warn("Connection to sequencer not active, will retry")
+			poolLog.Warn("Connection to sequencer not active, will retry")
 			return fmt.Errorf("connection to sequencer lost")
 		}
 
-		stream, err = p.createStream()
+		stream, err = p.createStream(ctx)
 		if err != nil {
 			return fmt.Errorf("stream creation failed: %w", err)
 		}
@@ -266,10 +432,10 @@ func (p *StreamPool) createNewStreamWithRetry() (network.Stream, error) {
 	}
 
 	backOff := backoff.NewExponentialBackOff()
-	backOff.MaxElapsedTime = config.SettingsObj.StreamHealthCheckTimeout
+	backOff.MaxElapsedTime = config.Current().StreamHealthCheckTimeout
 	backOff.InitialInterval = 100 * time.Millisecond
 
-	err := backoff.Retry(operation, backOff)
+	err = backoff.Retry(operation, backoff.WithContext(backOff, ctx))
 	if err != nil {
 		// Only terminate after all retries are exhausted
 		return nil, fmt.Errorf("failed to create stream after retries: %w", err)
@@ -286,7 +452,7 @@ func (p *StreamPool) Stop() {
 	// Aggressively close all streams
 	for _, stream := range p.streams {
 		if err := stream.Reset(); err != nil {
-			log.Warnf("Error resetting stream: %v", err)
+			poolLog.Warnf("Error resetting stream: %v", err)
 		}
 		stream.Close()
 	}
@@ -307,13 +473,13 @@ func (p *StreamPool) RemoveStream(s network.Stream) {
 			// Close the stream
 			s.Close()
 			// Log the removal
-			log.Debugf("Removed stream from pool. Current pool size: %d", len(p.streams))
+			poolLog.Debugf("Removed stream from pool. Current pool size: %d", len(p.streams))
 			return
 		}
 	}
 
 	// If we get here, the stream wasn't in the pool
-	log.Warn("Attempted to remove a stream that wasn't in the pool")
+	poolLog.Warn("Attempted to remove a stream that wasn't in the pool")
 	// Close the stream anyway, just in case
 	s.Close()
 }
@@ -326,11 +492,21 @@ func RebuildStreamPool() error {
 		return fmt.Errorf("cannot rebuild: stream pool not initialized")
 	}
 
+	// The refresh may have connected to a different sequencer
+	_, seqId, err := GetSequencerConnection()
+	if err != nil {
+		return fmt.Errorf("cannot rebuild: %w", err)
+	}
+	if previous := libp2pStreamPool.sequencer(); previous != seqId {
+		poolLog.Infof("Stream pool now targets sequencer %s (was %s)", seqId, previous)
+	}
+	libp2pStreamPool.sequencerID.Store(seqId)
+
 	// Close all existing streams
 	libp2pStreamPool.mu.Lock()
 	for _, stream := range libp2pStreamPool.streams {
 		if err := stream.Close(); err != nil {
-			log.Warnf("Error closing stream during rebuild: %v", err)
+			poolLog.Warnf("Error closing stream during rebuild: %v", err)
 		}
 	}
 
@@ -339,6 +515,6 @@ func RebuildStreamPool() error {
 	libp2pStreamPool.streams = make([]network.Stream, 0, maxSize)
 	libp2pStreamPool.mu.Unlock()
 
-	log.Info("Stream pool rebuilt after reconnection")
+	poolLog.Info("Stream pool rebuilt after reconnection")
 	return nil
 }
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"proto-snapshot-server/config"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn is a live connection; only IsClosed is implemented
+type fakeConn struct {
+	network.Conn
+}
+
+func (c *fakeConn) IsClosed() bool { return false }
+
+// fakeStream records what the collector does with a stream. Methods the
+// pool does not use are left to the embedded nil interface.
+type fakeStream struct {
+	network.Stream
+
+	mu              sync.Mutex
+	written         bytes.Buffer
+	response        *bytes.Reader
+	closedWrite     bool
+	closed          bool
+	reset           bool
+	readDeadline    time.Time
+	readDeadlineErr error
+	closeWriteErr   error
+}
+
+func newFakeStream(response string) *fakeStream {
+	return &fakeStream{response: bytes.NewReader([]byte(response))}
+}
+
+func (s *fakeStream) ID() string                       { return "fake" }
+func (s *fakeStream) Conn() network.Conn               { return &fakeConn{} }
+func (s *fakeStream) SetDeadline(time.Time) error      { return nil }
+func (s *fakeStream) SetWriteDeadline(time.Time) error { return nil }
+
+func (s *fakeStream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readDeadline = t
+	return s.readDeadlineErr
+}
+
+func (s *fakeStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written.Write(p)
+}
+
+func (s *fakeStream) Read(p []byte) (int, error) {
+	return s.response.Read(p)
+}
+
+func (s *fakeStream) CloseWrite() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closedWrite = true
+	return s.closeWriteErr
+}
+
+func (s *fakeStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeStream) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset = true
+	return nil
+}
+
+// useTestPool installs a stream pool holding streams for the duration of a
+// test
+func useTestPool(t *testing.T, ephemeral bool, streams ...network.Stream) *StreamPool {
+	pool := &StreamPool{
+		streams:   streams,
+		maxSize:   len(streams),
+		reqQueue:  make(chan *reqSlot, 1),
+		ephemeral: ephemeral,
+	}
+	libp2pStreamPoolMu.Lock()
+	previous := libp2pStreamPool
+	libp2pStreamPool = pool
+	libp2pStreamPoolMu.Unlock()
+	t.Cleanup(func() {
+		libp2pStreamPoolMu.Lock()
+		libp2pStreamPool = previous
+		libp2pStreamPoolMu.Unlock()
+	})
+	return pool
+}
+
+func TestGetStreamReleasesSlotWhenContextExpires(t *testing.T) {
+	useSettings(t, &config.Settings{SubmissionTimeout: time.Second, StreamHealthCheckTimeout: time.Second})
+	pool := useTestPool(t, false)
+
+	// Stream acquisition waits while a refresh is in progress
+	previous := connectionRefreshing.Swap(true)
+	t.Cleanup(func() { connectionRefreshing.Store(previous) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := pool.GetStream(ctx)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second, "must give up by the context deadline")
+	assert.Equal(t, 0, len(pool.reqQueue), "slot must be released")
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	_, err = pool.GetStream(cancelled)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 200*time.Millisecond, "must not wait out the submission timeout")
+	assert.Equal(t, 0, len(pool.reqQueue), "slot must be released")
+}
+
+func TestEphemeralCompleteWrite(t *testing.T) {
+	useSettings(t, &config.Settings{EphemeralReadResponse: true, EphemeralResponseTimeout: time.Second})
+
+	tests := []struct {
+		name            string
+		closeWriteErr   error
+		readDeadlineErr error
+		wantRead        bool
+		wantReset       bool
+	}{
+		{name: "Half-close and read response", wantRead: true},
+		{name: "Read deadline cannot be set", readDeadlineErr: errors.New("deadline unsupported")},
+		{name: "Half-close fails", closeWriteErr: errors.New("stream reset"), wantReset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A pool with no room for warm streams, so nothing is replenished
+			pool := &StreamPool{reqQueue: make(chan *reqSlot, 1), ephemeral: true}
+			slot := &reqSlot{id: "slot", createdAt: time.Now()}
+			pool.reqQueue <- slot
+
+			stream := newFakeStream("ack")
+			stream.closeWriteErr = tt.closeWriteErr
+			stream.readDeadlineErr = tt.readDeadlineErr
+			pool.CompleteWrite(&streamWithSlot{stream: stream, slot: slot})
+
+			assert.True(t, stream.closedWrite, "the write side is half-closed first")
+			assert.True(t, stream.closed)
+			assert.Equal(t, tt.wantReset, stream.reset)
+			assert.Equal(t, tt.wantRead, stream.response.Len() == 0, "response read")
+			if tt.closeWriteErr == nil {
+				assert.False(t, stream.readDeadline.IsZero(), "read deadline attempted")
+			}
+			assert.Equal(t, 0, len(pool.reqQueue), "slot must be released")
+			assert.Equal(t, 0, pool.Stats().Idle, "ephemeral streams are not reused")
+		})
+	}
+}
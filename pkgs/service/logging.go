@@ -0,0 +1,29 @@
+package service
+
+import (
+	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// submissionLogger returns a log entry carrying the standard submission fields
+func submissionLogger(submissionId string, request *pkgs.Request) *log.Entry {
+	return grpcLog.WithFields(log.Fields{
+		helpers.FieldSubmissionID: submissionId,
+		helpers.FieldEpochID:      request.EpochId,
+		helpers.FieldProjectID:    request.ProjectId,
+		helpers.FieldSlotID:       request.SlotId,
+	})
+}
+
+// Per-subsystem loggers; their levels can be changed at runtime
+var (
+	grpcLog      = helpers.SubsystemLogger(helpers.SubsystemGRPC)
+	poolLog      = helpers.SubsystemLogger(helpers.SubsystemPool)
+	connLog      = helpers.SubsystemLogger(helpers.SubsystemConnection)
+	discoveryLog = helpers.SubsystemLogger(helpers.SubsystemDiscovery)
+	reportingLog = helpers.SubsystemLogger(helpers.SubsystemReporting)
+	ledgerLog    = helpers.SubsystemLogger(helpers.SubsystemLedger)
+	adminLog     = helpers.SubsystemLogger(helpers.SubsystemAdmin)
+)
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"proto-snapshot-server/config"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const metricsNamespace = "local_collector"
+
+// Pipeline stages timed by submissionStageDuration
+const (
+	stageQueue         = "queue"          // Waiting in the async submission queue
+	stagePermit        = "permit"         // Waiting for a write permit
+	stageStreamAcquire = "stream_acquire" // Getting a stream from the pool
+	stageStreamWrite   = "stream_write"   // The stream write itself
+	stageTotal         = "total"          // Receipt to final outcome
+)
+
+var (
+	submissionsReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "submissions_received_total",
+		Help:      "Snapshot submissions received over gRPC.",
+	})
+	submissionsSucceeded = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "submissions_succeeded_total",
+		Help:      "Snapshot submissions written to the sequencer.",
+	})
+	submissionsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "submissions_failed_total",
+		Help:      "Snapshot submissions that were not written, by failure reason.",
+	}, []string{"reason"})
+	submissionStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "submission_stage_duration_seconds",
+		Help:      "Time spent by submissions in each pipeline stage.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"stage"})
+
+	streamsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "streams_created_total",
+		Help:      "Streams opened to the sequencer.",
+	})
+	streamsDiscarded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "streams_discarded_total",
+		Help:      "Streams closed instead of being reused, by reason.",
+	}, []string{"reason"})
+
+	connectionRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "connection_refreshes_total",
+		Help:      "Sequencer connection refresh cycles, by result.",
+	}, []string{"result"})
+	connectionRefreshDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "connection_refresh_duration_seconds",
+		Help:      "Duration of sequencer connection refresh cycles.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	issueReports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "issue_reports_total",
+		Help:      "Issues handled by the reporting service, by issue type and outcome.",
+	}, []string{"issue_type", "result"})
+
+	submissionsPastDeadline = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "submissions_past_deadline_total",
+		Help:      "Submissions received after their deadline block, by action taken.",
+	}, []string{"action"})
+	submissionsInvalid = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "submissions_invalid_total",
+		Help:      "Submissions that failed validation, by action taken.",
+	}, []string{"action"})
+)
+
+func init() {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "chain_head_block",
+		Help:      "Latest block reported by the chain head provider, 0 when unknown.",
+	}, func() float64 {
+		if head := currentChainHead(); head != nil {
+			if block, ok := head.CurrentBlock(); ok {
+				return float64(block)
+			}
+		}
+		return 0
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "connection_refreshing",
+		Help:      "1 while a connection refresh cycle is in progress.",
+	}, func() float64 {
+		return boolToFloat(connectionRefreshing.Load())
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "sequencer_connected",
+		Help:      "1 if the host is connected to the sequencer.",
+	}, func() float64 {
+		hostConn, seqId, err := GetSequencerConnection()
+		if err != nil {
+			return 0
+		}
+		return boolToFloat(hostConn.Network().Connectedness(seqId) == network.Connected)
+	})
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "stream_pool_idle_streams",
+		Help:      "Streams currently idle in the pool.",
+	}, func() float64 {
+		return float64(poolStats().Idle)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "stream_pool_max_streams",
+		Help:      "Maximum number of idle streams the pool keeps.",
+	}, func() float64 {
+		return float64(poolStats().MaxSize)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "stream_request_queue_depth",
+		Help:      "Occupied stream request queue slots.",
+	}, func() float64 {
+		return float64(poolStats().QueuedRequests)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "stream_request_queue_capacity",
+		Help:      "Capacity of the stream request queue.",
+	}, func() float64 {
+		return float64(poolStats().QueueCapacity)
+	})
+}
+
+// registerServerMetrics exposes occupancy and per-epoch series read from s.
+// The series are registered once; a server created later, as in tests, takes
+// them over.
+func registerServerMetrics(s *server) {
+	collector := &serverCollector{}
+	collector.server.Store(s)
+	err := prometheus.Register(collector)
+	if err == nil {
+		return
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(*serverCollector); ok {
+			existing.server.Store(s)
+			return
+		}
+	}
+	log.Warnf("⚠️ Server metrics not registered: %v", err)
+}
+
+// serverCollector reports write permits, lane occupancy and the retained
+// epoch window at scrape time
+type serverCollector struct {
+	server atomic.Pointer[server]
+}
+
+var (
+	permitsInFlightDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "write_permits_in_flight"),
+		"Write permits currently held.", nil, nil)
+	permitsLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "write_permits_limit"),
+		"Maximum number of concurrent stream writes.", nil, nil)
+	permitWaitersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "write_permit_waiters"),
+		"Callers waiting for a write permit, by lane.", []string{"lane"}, nil)
+	submissionQueueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "submission_queue_depth"),
+		"Submissions waiting in the async queue, by lane.", []string{"lane"}, nil)
+	epochSubmissionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "epoch_submissions"),
+		"Submissions for each retained epoch, plus the simulation and stale buckets, by outcome.", []string{"epoch", "outcome"}, nil)
+	epochFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "epoch_submission_failures"),
+		"Failed submissions for each retained epoch, plus the simulation and stale buckets, by failure reason.", []string{"epoch", "reason"}, nil)
+)
+
+func (c *serverCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- permitsInFlightDesc
+	ch <- permitsLimitDesc
+	ch <- permitWaitersDesc
+	ch <- submissionQueueDepthDesc
+	ch <- epochSubmissionsDesc
+	ch <- epochFailuresDesc
+}
+
+func (c *serverCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.server.Load()
+	if s == nil {
+		return
+	}
+	permits := s.writePermits.stats()
+	ch <- prometheus.MustNewConstMetric(permitsInFlightDesc, prometheus.GaugeValue, float64(permits.InFlight))
+	ch <- prometheus.MustNewConstMetric(permitsLimitDesc, prometheus.GaugeValue, float64(permits.Limit))
+
+	var queued [laneCount]int
+	if s.dispatcher != nil {
+		queued = s.dispatcher.pending()
+	}
+	for l := submissionLane(0); l < laneCount; l++ {
+		ch <- prometheus.MustNewConstMetric(permitWaitersDesc, prometheus.GaugeValue, float64(permits.Waiting[l]), l.String())
+		ch <- prometheus.MustNewConstMetric(submissionQueueDepthDesc, prometheus.GaugeValue, float64(queued[l]), l.String())
+	}
+
+	epochs := s.epochs.report()
+	collectEpoch := func(epoch string, m EpochStatus) {
+		ch <- prometheus.MustNewConstMetric(epochSubmissionsDesc, prometheus.GaugeValue, float64(m.Received), epoch, "received")
+		ch <- prometheus.MustNewConstMetric(epochSubmissionsDesc, prometheus.GaugeValue, float64(m.Succeeded), epoch, "succeeded")
+		ch <- prometheus.MustNewConstMetric(epochSubmissionsDesc, prometheus.GaugeValue, float64(m.Failed), epoch, "failed")
+		for reason, n := range m.Failures {
+			ch <- prometheus.MustNewConstMetric(epochFailuresDesc, prometheus.GaugeValue, float64(n), epoch, reason)
+		}
+	}
+	for epochID, m := range epochs.Epochs {
+		collectEpoch(strconv.FormatUint(epochID, 10), m)
+	}
+	collectEpoch("simulation", epochs.Simulation)
+	collectEpoch("stale", epochs.Stale)
+}
+
+// StartMetricsServer serves Prometheus metrics on the configured host and port
+func StartMetricsServer() {
+	if !config.Current().MetricsEnabled {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	addr := net.JoinHostPort(config.Current().MetricsHost, config.Current().MetricsPort)
+	log.Infof("📊 Metrics server listening at %s", addr)
+	if err := serveHTTP(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("❌ Metrics server stopped: %v", err)
+	}
+}
+
+// failureReason maps a submission error onto a low-cardinality label
+func failureReason(err error) string {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return "invalid"
+	case codes.Canceled:
+		return "canceled"
+	case codes.DeadlineExceeded:
+		return "deadline_exceeded"
+	case codes.ResourceExhausted:
+		return "queue_full"
+	case codes.Unavailable:
+		return "unavailable"
+	case codes.FailedPrecondition:
+		return "past_deadline"
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "request queue full"):
+		return "stream_queue_full"
+	case strings.Contains(msg, "stream pool not available"),
+		strings.Contains(msg, "failed to acquire stream"):
+		return "stream_unavailable"
+	case strings.Contains(msg, "Write failed"),
+		strings.Contains(msg, "Incomplete write"),
+		strings.Contains(msg, "write deadline"):
+		return "write_failed"
+	default:
+		return "other"
+	}
+}
+
+func observeStage(stage string, since time.Time) {
+	submissionStageDuration.WithLabelValues(stage).Observe(time.Since(since).Seconds())
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerMetricsFollowLatestServerAndReportFailureReasons(t *testing.T) {
+	newServer := func() *server {
+		return &server{
+			writePermits: newWritePermitScheduler(4, 1, 0, [laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1}),
+			epochs:       newEpochTracker(4),
+		}
+	}
+	first, second := newServer(), newServer()
+	assert.NotPanics(t, func() {
+		registerServerMetrics(first)
+		registerServerMetrics(second)
+	})
+
+	m := second.epochs.metricsFor(7)
+	m.failed.Add(2)
+	m.observe(time.Second, "deadline_exceeded")
+	m.observe(time.Second, "deadline_exceeded")
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+
+	var failures float64
+	for _, family := range families {
+		if family.GetName() != "local_collector_epoch_submission_failures" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["epoch"] == "7" && labels["reason"] == "deadline_exceeded" {
+				failures = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(2), failures)
+}
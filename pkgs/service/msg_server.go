@@ -7,29 +7,33 @@ import (
 	"net"
 	"proto-snapshot-server/config"
 	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
+	"sort"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// epochMetrics tracks submission statistics for a specific epoch
-type epochMetrics struct {
-	received  atomic.Uint64
-	succeeded atomic.Uint64
-}
-
 // server is used to implement submission.SubmissionService.
 type server struct {
 	pkgs.UnimplementedSubmissionServer
-	writeSemaphore chan struct{} // Control concurrent writes
-	metrics        *sync.Map     // map[uint64]*epochMetrics
-	currentEpoch   atomic.Uint64
+	writePermits *writePermitScheduler // Control concurrent writes
+	epochs       *epochTracker         // Per-epoch submission metrics
+	dispatcher   *submissionDispatcher // nil unless async mode is enabled
+	batcher      *submissionBatcher    // nil unless batching is enabled
+	paused       atomic.Bool           // Reject new submissions while set
+	delivering   atomic.Int64          // Submissions being accepted or written, outside the async queue
+	successLogs  *helpers.Sampler      // Thins out per-write success logs
+	stopLoops    context.CancelFunc    // Stops the periodic background loops
 }
 
 var _ pkgs.SubmissionServer = &server{}
@@ -40,26 +44,55 @@ func NewMsgServerImplV2() pkgs.SubmissionServer {
 	deps.mu.RLock()
 	if !deps.initialized {
 		deps.mu.RUnlock()
-		log.Fatal("Cannot create server: service not initialized")
+		grpcLog.Fatal("Cannot create server: service not initialized")
 	}
 	deps.mu.RUnlock()
 
 	server := &server{
-		writeSemaphore: make(chan struct{}, config.SettingsObj.MaxConcurrentWrites),
-		metrics:        &sync.Map{},
+		writePermits: newWritePermitScheduler(
+			config.Current().MaxConcurrentWrites,
+			config.Current().LaneQueueSize,
+			config.Current().SimulationMaxInFlight,
+			laneWeights(),
+		),
+		epochs:      newEpochTracker(config.Current().EpochMetricsWindow),
+		successLogs: helpers.NewSampler(config.Current().LogSuccessSampleEvery),
+	}
+
+	server.epochs.setJumpGuard(config.Current().EpochMaxAdvance,
+		config.Current().EpochJumpConfirmations, config.Current().EpochAnchorTimeout)
+
+	if config.Current().BatchSubmissions {
+		server.batcher = newSubmissionBatcher(server, config.Current().BatchWindow,
+			config.Current().BatchMaxCount, config.Current().BatchMaxBytes)
+		go server.batcher.run()
+		grpcLog.Infof("📦 Submission batching enabled (window: %v, max count: %d, max bytes: %d)",
+			config.Current().BatchWindow, config.Current().BatchMaxCount, config.Current().BatchMaxBytes)
+	}
+
+	if config.Current().AsyncSubmissionMode {
+		server.dispatcher = newSubmissionDispatcher(server, config.Current().SubmissionQueueSize, laneWeights())
+		server.dispatcher.start(config.Current().WorkerPoolSize)
+		grpcLog.Infof("⚡ Async submission mode enabled with %d workers and queue size %d",
+			config.Current().WorkerPoolSize, config.Current().SubmissionQueueSize)
 	}
 
+	registerServerMetrics(server)
+
 	// Start periodic metrics logging with 15 second interval
 	go server.logMetricsPeriodically(15 * time.Second)
+	var loops context.Context
+	loops, server.stopLoops = context.WithCancel(context.Background())
+	go server.closeEpochsPeriodically(loops, time.Second)
 
 	return server
 }
 
 func StartSubmissionServer(server pkgs.SubmissionServer) {
 	// Create a TCP listener on the specified port from the configuration
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", config.SettingsObj.PortNumber))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", config.Current().PortNumber))
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		grpcLog.Fatalf("failed to listen: %v", err)
 	}
 
 	// Create a new gRPC server instance
@@ -67,204 +100,348 @@ func StartSubmissionServer(server pkgs.SubmissionServer) {
 
 	// Register the SubmissionServer with the gRPC server
 	pkgs.RegisterSubmissionServer(grpcServer, server)
-	log.Printf("Server listening at %v", listener.Addr())
+	grpcLog.Printf("Server listening at %v", listener.Addr())
 
 	// Start serving requests
 	if err := grpcServer.Serve(listener); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		grpcLog.Fatalf("failed to serve: %v", err)
 	}
 }
 
 func (s *server) SubmitSnapshot(ctx context.Context, submission *pkgs.SnapshotSubmission) (*pkgs.SubmissionResponse, error) {
-	log.Debugln("Received submission with request: ", submission.Request)
+	ctx, span := tracer.Start(extractTraceContext(ctx), "SubmitSnapshot", trace.WithSpanKind(trace.SpanKindServer))
+	response, err := s.submitSnapshot(ctx, submission)
+	endSpan(span, err)
+	return response, err
+}
+
+func (s *server) submitSnapshot(ctx context.Context, submission *pkgs.SnapshotSubmission) (*pkgs.SubmissionResponse, error) {
+	grpcLog.Debugln("Received submission with request: ", submission.GetRequest())
+
+	submissionsReceived.Inc()
+	// Counted before the pause check so Drain cannot miss a submission that
+	// got past it
+	s.delivering.Add(1)
+	defer s.delivering.Add(-1)
+	if s.paused.Load() {
+		err := status.Error(codes.Unavailable, "collector ingest is paused")
+		submissionsFailed.WithLabelValues(failureReason(err)).Inc()
+		return &pkgs.SubmissionResponse{Message: "Failure"}, err
+	}
+	if err := checkSubmission(submission); err != nil {
+		submissionsFailed.WithLabelValues(failureReason(err)).Inc()
+		return &pkgs.SubmissionResponse{Message: "Failure"}, err
+	}
+	checkDataMarket(submission)
 
 	submissionId := uuid.New()
+	if err := checkDeadline(submissionId.String(), submission.Request); err != nil {
+		submissionsFailed.WithLabelValues(failureReason(err)).Inc()
+		return &pkgs.SubmissionResponse{Message: "Failure"}, err
+	}
 	submissionIdBytes, err := submissionId.MarshalText()
 	if err != nil {
-		log.Errorln("Error marshalling submissionId: ", err.Error())
+		grpcLog.Errorln("Error marshalling submissionId: ", err.Error())
 		return &pkgs.SubmissionResponse{Message: "Failure"}, err
 	}
 
 	subBytes, err := json.Marshal(submission)
 	if err != nil {
-		log.Errorln("Could not marshal submission: ", err.Error())
+		grpcLog.Errorln("Could not marshal submission: ", err.Error())
 		return &pkgs.SubmissionResponse{Message: "Failure"}, err
 	}
-	log.Debugln("Sending submission with ID: ", submissionId.String())
+	submissionLogger(submissionId.String(), submission.Request).Debug("Sending submission")
+	trace.SpanFromContext(ctx).SetAttributes(submissionAttributes(submissionId.String(), submission.Request)...)
 
 	submissionBytes := submissionIdBytes
 	submissionBytes = append(submissionBytes, subBytes...)
 
 	// Track received submission for this epoch
-	metrics := s.getOrCreateEpochMetrics(submission.Request.EpochId)
-	metrics.received.Add(1)
+	metrics := s.epochs.metricsFor(submission.Request.EpochId)
+	lane := s.laneFor(submission.Request)
+	metrics.receive(submission.Request)
+
+	item := &queuedSubmission{
+		id:          submissionId.String(),
+		data:        submissionBytes,
+		submission:  submission,
+		metrics:     metrics,
+		lane:        lane,
+		enqueuedAt:  time.Now(),
+		spanContext: trace.SpanContextFromContext(ctx),
+	}
 
-	// Single write attempt with backoff
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = 30 * time.Second
-
-	err = backoff.Retry(func() error {
-		// First get writeSemaphore for GRPC concurrency control
-		select {
-		case s.writeSemaphore <- struct{}{}:
-			defer func() { <-s.writeSemaphore }()
-		default:
-			return fmt.Errorf("server at capacity") // Non-retriable
+	// In async mode the submission is handed to the worker pool and
+	// acknowledged without waiting for the stream write
+	if s.dispatcher != nil {
+		if err := s.dispatcher.enqueue(item); err != nil {
+			submissionLogger(submissionId.String(), submission.Request).WithError(err).Warn("🚫 Could not queue submission")
+			s.recordOutcome(item, err)
+			return &pkgs.SubmissionResponse{Message: "Failure"}, err
+		}
+		return &pkgs.SubmissionResponse{Message: "Accepted"}, nil
+	}
+
+	// Bound the whole pipeline by the client deadline, falling back to the
+	// configured submission timeout when the caller did not set one
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Current().SubmissionTimeout)
+		defer cancel()
+	}
+
+	if err := s.deliver(ctx, item); err != nil {
+		return &pkgs.SubmissionResponse{Message: "Failure"}, err
+	}
+
+	return &pkgs.SubmissionResponse{Message: "Success"}, nil
+}
+
+// deliver routes a submission through the batcher when batching is enabled,
+// otherwise it is written on a stream of its own
+func (s *server) deliver(ctx context.Context, item *queuedSubmission) (err error) {
+	ctx, span := tracer.Start(ctx, "deliver", trace.WithAttributes(
+		append(submissionAttributes(item.id, item.submission.Request), attribute.String("submission.lane", item.lane.String()))...,
+	))
+	defer func() { endSpan(span, err) }()
+
+	if s.batcher != nil {
+		return s.batcher.submit(ctx, item)
+	}
+	return s.deliverSubmission(ctx, item)
+}
+
+// deliverSubmission writes a marshalled submission to the sequencer, retrying
+// transient pool errors until ctx is done
+func (s *server) deliverSubmission(ctx context.Context, item *queuedSubmission) error {
+	submissionId, submission := item.id, item.submission
+	err := s.writeWithRetry(ctx, item.lane, func() error {
+		return s.writeToStream(ctx, item.data, submissionId, submission)
+	})
+
+	if err != nil {
+		entry := submissionLogger(submissionId, submission.Request)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			entry.WithError(ctxErr).Warn("⌛ Abandoned submission")
+			err = status.FromContextError(ctxErr).Err()
+		} else {
+			entry.WithError(err).Error("❌ Failed to submit snapshot after retries")
+			reportIssue(IssueStreamWriteFailure, submission.Request, err, IssueDetails{
+				"submissionId": submissionId,
+				"slotId":       submission.Request.SlotId,
+				"snapshotCid":  submission.Request.SnapshotCid,
+			})
+		}
+	}
+	s.recordOutcome(item, err)
+	return err
+}
+
+// recordOutcome updates epoch and Prometheus counters once a submission has
+// either been written or given up on
+func (s *server) recordOutcome(item *queuedSubmission, err error) {
+	observeStage(stageTotal, item.enqueuedAt)
+	request := item.submission.Request
+	entry := LedgerRecord{
+		SubmissionID: item.id,
+		EpochID:      request.EpochId,
+		ProjectID:    request.ProjectId,
+		SlotID:       request.SlotId,
+		SnapshotCID:  request.SnapshotCid,
+		ReceivedAt:   item.enqueuedAt,
+		CompletedAt:  time.Now(),
+		Outcome:      LedgerSucceeded,
+	}
+	if err != nil {
+		reason := failureReason(err)
+		item.metrics.failed.Add(1)
+		item.metrics.observe(entry.CompletedAt.Sub(item.enqueuedAt), reason)
+		submissionsFailed.WithLabelValues(reason).Inc()
+		entry.Outcome, entry.Reason, entry.Error = LedgerFailed, reason, err.Error()
+		ledger.Load().record(entry)
+		return
+	}
+	item.metrics.succeeded.Add(1)
+	item.metrics.observe(entry.CompletedAt.Sub(item.enqueuedAt), "")
+	submissionsSucceeded.Inc()
+	ledger.Load().record(entry)
+}
+
+// writeWithRetry runs write under a write permit for lane, retrying transient
+// pool errors up to MaxWriteRetries times while ctx is live
+func (s *server) writeWithRetry(ctx context.Context, lane submissionLane, write func() error) error {
+	b := backoff.WithMaxRetries(newContextBackOff(ctx, backoff.DefaultInitialInterval),
+		uint64(config.Current().MaxWriteRetries))
+
+	return backoff.Retry(func() error {
+		// First get a write permit for GRPC concurrency control, waiting our
+		// lane's turn
+		permitStart := time.Now()
+		_, permitSpan := tracer.Start(ctx, "acquire_write_permit",
+			trace.WithAttributes(attribute.String("submission.lane", lane.String())))
+		err := s.writePermits.acquire(ctx, lane)
+		endSpan(permitSpan, err)
+		if err != nil {
+			return backoff.Permanent(err)
 		}
+		observeStage(stagePermit, permitStart)
+		defer s.writePermits.release(lane)
 
 		// Then try to write
-		if err := s.writeToStream(submissionBytes, submissionId.String(), submission); err != nil {
+		if err := write(); err != nil {
+			if ctx.Err() != nil {
+				return backoff.Permanent(err)
+			}
 			if strings.Contains(err.Error(), "request queue full") ||
 				strings.Contains(err.Error(), "connection refresh in progress") {
 				return err // Retriable
 			}
 			return backoff.Permanent(err)
 		}
-		metrics.succeeded.Add(1)
 		return nil
 	}, b)
+}
 
-	if err != nil {
-		log.Errorf("❌ Failed to submit snapshot after retries: %v", err)
-		return &pkgs.SubmissionResponse{Message: "Failure"}, err
+// checkDataMarket reports submissions signed for a data market other than the
+// configured one. The request signature is bound to the data market contract,
+// so the sequencer will not accept it for ours.
+func checkDataMarket(submission *pkgs.SnapshotSubmission) {
+	if !config.Current().DataMarketInRequest || submission.DataMarket == "" ||
+		strings.EqualFold(submission.DataMarket, config.Current().DataMarketAddress) {
+		return
 	}
-
-	return &pkgs.SubmissionResponse{Message: "Success"}, nil
+	grpcLog.WithField(helpers.FieldProjectID, submission.Request.ProjectId).
+		Warnf("⚠️ Submission signed for data market %s, expected %s", submission.DataMarket, config.Current().DataMarketAddress)
+	reportIssue(IssueSignatureMismatch, submission.Request, nil, IssueDetails{
+		"submissionDataMarket": submission.DataMarket,
+		"expectedDataMarket":   config.Current().DataMarketAddress,
+		"slotId":               submission.Request.SlotId,
+	})
 }
 
 func (s *server) SubmitSnapshotSimulation(stream pkgs.Submission_SubmitSnapshotSimulationServer) error {
 	return nil // not implemented, will remove
 }
 
-func (s *server) writeToStream(data []byte, submissionId string, submission *pkgs.SnapshotSubmission) error {
-	log.Debugf("📝 Starting stream write for submission %s", submissionId)
+func (s *server) writeToStream(ctx context.Context, data []byte, submissionId string, submission *pkgs.SnapshotSubmission) error {
+	entry := submissionLogger(submissionId, submission.Request)
+	entry.Debug("📝 Starting stream write")
+
+	label := fmt.Sprintf("submission (Project: %s, Epoch: %d) with ID: %s",
+		submission.Request.ProjectId, submission.Request.EpochId, submissionId)
+	entry, err := writeFrame(ctx, data, label, entry)
+	if err != nil {
+		return err
+	}
+
+	if !s.successLogs.Sample() {
+		return nil
+	}
+	if submission.Request.EpochId == 0 {
+		entry.Info("✅ Successfully wrote to stream for SIMULATION snapshot submission")
+	} else {
+		entry.Info("✅ Successfully wrote to stream for snapshot submission")
+	}
+	return nil
+}
 
+// writeFrame acquires a pooled stream and writes data to it in one call.
+// label describes the payload in errors and entry carries its log fields; the
+// returned entry adds the stream and sequencer the frame was written to.
+func writeFrame(ctx context.Context, data []byte, label string, entry *log.Entry) (*log.Entry, error) {
 	pool := GetLibp2pStreamPool()
 	if pool == nil {
-		return fmt.Errorf("❌ stream pool not available")
+		return entry, fmt.Errorf("❌ stream pool not available")
 	}
 
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = 30 * time.Second
+	b := newContextBackOff(ctx, backoff.DefaultInitialInterval)
 
 	var sw *streamWithSlot
 	attempt := 0
+	acquireStart := time.Now()
+	acquireCtx, acquireSpan := tracer.Start(ctx, "GetStream")
 	err := backoff.Retry(func() error {
 		attempt++
-		log.Debugf("🔄 Attempting to get stream (attempt %d)", attempt)
-		s, err := pool.GetStream()
+		grpcLog.Debugf("🔄 Attempting to get stream (attempt %d)", attempt)
+		s, err := pool.GetStream(acquireCtx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return backoff.Permanent(err)
+			}
 			if strings.Contains(err.Error(), "connection refresh in progress") {
-				log.Debugf("⏳ Waiting for connection refresh to complete (attempt %d)", attempt)
+				grpcLog.Debugf("⏳ Waiting for connection refresh to complete (attempt %d)", attempt)
 				return err
 			}
-			log.Debugf("❌ Non-retriable error getting stream: %v", err)
+			grpcLog.Debugf("❌ Non-retriable error getting stream: %v", err)
 			return backoff.Permanent(err)
 		}
 		sw = s
-		log.Debug("✅ Successfully acquired stream")
+		grpcLog.Debug("✅ Successfully acquired stream")
 		return nil
 	}, b)
 
+	acquireSpan.SetAttributes(attribute.Int("stream.attempts", attempt))
+	endSpan(acquireSpan, err)
 	if err != nil {
-		return err
+		return entry, err
 	}
+	entry = entry.WithFields(log.Fields{
+		helpers.FieldStreamID:    sw.stream.ID(),
+		helpers.FieldSequencerID: pool.sequencer().String(),
+	})
+	observeStage(stageStreamAcquire, acquireStart)
 
-	// Set write deadline before attempting write
-	if err := sw.stream.SetWriteDeadline(time.Now().Add(config.SettingsObj.StreamWriteTimeout)); err != nil {
+	// Never write a submission the client has already given up on
+	if err := ctx.Err(); err != nil {
+		pool.ReleaseStream(sw, false)
+		return entry, fmt.Errorf("⌛ Expired before write: %s: %w", label, err)
+	}
+
+	// Set write deadline before attempting write, never past the client deadline
+	writeDeadline := time.Now().Add(config.Current().StreamWriteTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(writeDeadline) {
+		writeDeadline = ctxDeadline
+	}
+	if err := sw.stream.SetWriteDeadline(writeDeadline); err != nil {
 		// First cleanup stream, then release slot
 		pool.ReleaseStream(sw, true)
-		return fmt.Errorf("❌ Failed to set write deadline for submission (Project: %s, Epoch: %d) with ID: %s: %w",
-			submission.Request.ProjectId, submission.Request.EpochId, submissionId, err)
+		return entry, fmt.Errorf("❌ Failed to set write deadline for %s: %w", label, err)
 	}
 
 	// Attempt the write
-	log.WithFields(log.Fields{
-		"submissionID": submissionId,
-		"streamID":     sw.stream.ID(),
-	}).Trace("Attempting stream write")
+	entry.Trace("Attempting stream write")
+	writeStart := time.Now()
+	_, writeSpan := tracer.Start(ctx, "stream.Write", trace.WithAttributes(
+		attribute.String("stream.id", sw.stream.ID()),
+		attribute.Int("stream.bytes", len(data)),
+	))
 	n, err := sw.stream.Write(data)
-	log.WithFields(log.Fields{
-		"submissionID": submissionId,
-		"streamID":     sw.stream.ID(),
-		"bytesWritten": n,
-		"error":        err,
+	endSpan(writeSpan, err)
+	observeStage(stageStreamWrite, writeStart)
+	entry.WithFields(log.Fields{
+		"bytes_written": n,
+		"error":         err,
 	}).Trace("Stream write completed")
 	if err != nil {
 		// First cleanup stream, then release slot
 		pool.ReleaseStream(sw, true)
-		return fmt.Errorf("❌ Write failed for submission (Project: %s, Epoch: %d) with ID: %s: %w",
-			submission.Request.ProjectId, submission.Request.EpochId, submissionId, err)
+		return entry, fmt.Errorf("❌ Write failed for %s: %w", label, err)
 	}
 
 	if n != len(data) {
 		// First cleanup stream, then release slot
 		pool.ReleaseStream(sw, true)
-		return fmt.Errorf("❌ Incomplete write: %d/%d bytes for submission (Project: %s, Epoch: %d) with ID: %s",
-			n, len(data), submission.Request.ProjectId, submission.Request.EpochId, submissionId)
+		return entry, fmt.Errorf("❌ Incomplete write: %d/%d bytes for %s", n, len(data), label)
 	}
 
-	// Return stream to pool and release slot
-	pool.ReleaseStream(sw, false)
-
-	if submission.Request.EpochId == 0 {
-		log.Infof("✅ Successfully wrote to stream for SIMULATION snapshot submission (Project: %s, Epoch: %d) with ID: %s",
-			submission.Request.ProjectId, submission.Request.EpochId, submissionId)
-	} else {
-		log.Infof("✅ Successfully wrote to stream for snapshot submission (Project: %s, Epoch: %d) with ID: %s",
-			submission.Request.ProjectId, submission.Request.EpochId, submissionId)
-	}
-	return nil
+	// Return stream to pool (or finish it, in ephemeral mode) and release slot
+	pool.CompleteWrite(sw)
+	return entry, nil
 }
 
-func (s *server) getOrCreateEpochMetrics(epochID uint64) *epochMetrics {
-	// Store current epoch
-	s.currentEpoch.Store(epochID)
-
-	// Get or create metrics for this epoch
-	metricsValue, _ := s.metrics.LoadOrStore(epochID, &epochMetrics{
-		received:  atomic.Uint64{},
-		succeeded: atomic.Uint64{},
-	})
-
-	// Type assert and return the metrics object
-	metrics := metricsValue.(*epochMetrics)
-
-	// Cleanup old epochs
-	s.metrics.Range(func(key, value interface{}) bool {
-		epoch := key.(uint64)
-		if epochID-epoch > 3 {
-			s.metrics.Delete(epoch)
-		}
-		return true
-	})
-
-	return metrics
-}
-
-func (s *server) GetMetrics() map[uint64]struct {
-	Received  uint64
-	Succeeded uint64
-} {
-	result := make(map[uint64]struct {
-		Received  uint64
-		Succeeded uint64
-	})
-
-	s.metrics.Range(func(key, value interface{}) bool {
-		epochID := key.(uint64)
-		metrics := value.(*epochMetrics)
-		result[epochID] = struct {
-			Received  uint64
-			Succeeded uint64
-		}{
-			Received:  metrics.received.Load(),
-			Succeeded: metrics.succeeded.Load(),
-		}
-		return true
-	})
-
-	return result
+// GetMetrics returns the status of the epochs in the metrics window
+func (s *server) GetMetrics() map[uint64]EpochStatus {
+	return s.epochs.report().Epochs
 }
 
 func (s *server) logMetricsPeriodically(interval time.Duration) {
@@ -272,41 +449,59 @@ func (s *server) logMetricsPeriodically(interval time.Duration) {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		currentEpoch := s.currentEpoch.Load()
-		metrics := s.GetMetrics()
-
-		log.WithFields(log.Fields{
-			"current_epoch": currentEpoch,
-			"metrics":       metrics,
+		report := s.epochs.report()
+
+		grpcLog.WithFields(log.Fields{
+			"current_epoch":        report.Latest,
+			"tracked_epochs":       len(report.Epochs),
+			"simulation_received":  report.Simulation.Received,
+			"simulation_succeeded": report.Simulation.Succeeded,
+			"stale_received":       report.Stale.Received,
 		}).Info("📊 Periodic metrics report")
 
-		// Detailed per-epoch logging
-		for epochID, m := range metrics {
+		// Detailed per-epoch logging, oldest first
+		epochIDs := make([]uint64, 0, len(report.Epochs))
+		for epochID := range report.Epochs {
+			epochIDs = append(epochIDs, epochID)
+		}
+		sort.Slice(epochIDs, func(i, j int) bool { return epochIDs[i] < epochIDs[j] })
+		for _, epochID := range epochIDs {
+			m := report.Epochs[epochID]
 			successRate := float64(0)
 			if m.Received > 0 {
 				successRate = float64(m.Succeeded) / float64(m.Received) * 100
 			}
 
-			log.WithFields(log.Fields{
-				"epoch_id":     epochID,
-				"received":     m.Received,
-				"succeeded":    m.Succeeded,
-				"success_rate": fmt.Sprintf("%.2f%%", successRate),
+			grpcLog.WithFields(log.Fields{
+				"epoch_id":       epochID,
+				"received":       m.Received,
+				"succeeded":      m.Succeeded,
+				"failed":         m.Failed,
+				"success_rate":   fmt.Sprintf("%.2f%%", successRate),
+				"latency_p50_ms": m.LatencyP50Ms,
+				"latency_p99_ms": m.LatencyP99Ms,
+				"failures":       m.Failures,
 			}).Info("📈 Epoch metrics")
 		}
 	}
 }
 
 func (s *server) GracefulShutdown() {
-	log.Info("Starting graceful shutdown...")
+	grpcLog.Info("Starting graceful shutdown...")
 
-	// Wait for all ongoing writes to complete
-	for i := 0; i < cap(s.writeSemaphore); i++ {
-		s.writeSemaphore <- struct{}{}
+	// Drain queued async submissions before blocking new writes
+	if s.dispatcher != nil {
+		s.dispatcher.stop()
+	}
+	if s.batcher != nil {
+		s.batcher.stop()
 	}
 
-	// Close the write semaphore to stop accepting new writes
-	close(s.writeSemaphore)
+	// Stop accepting new writes and wait for all ongoing writes to complete
+	s.writePermits.close()
+	if s.stopLoops != nil {
+		s.stopLoops()
+	}
 
 	// Stop the gRPC server gracefully
 	grpcServer.GracefulStop()
@@ -316,15 +511,31 @@ func (s *server) GracefulShutdown() {
 		pool.Stop()
 	}
 
-	log.Info("🧹 Graceful shutdown complete")
+	grpcLog.Info("🧹 Graceful shutdown complete")
+}
+
+// newContextBackOff returns an exponential backoff that stops retrying once
+// ctx is done. Its elapsed-time budget follows the context deadline, or the
+// configured submission timeout when ctx has none.
+func newContextBackOff(ctx context.Context, initialInterval time.Duration) backoff.BackOffContext {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = initialInterval
+	b.MaxElapsedTime = config.Current().SubmissionTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		b.MaxElapsedTime = time.Until(deadline)
+	}
+	return backoff.WithContext(b, ctx)
 }
 
 // GracefulShutdownServer initiates the graceful shutdown for the provided SubmissionServer
 func GracefulShutdownServer(s pkgs.SubmissionServer) {
+	// The admin and metrics servers stay up while submissions drain
+	defer shutdownHTTPServers()
+
 	if srv, ok := s.(*server); ok {
 		srv.GracefulShutdown() // Trigger the graceful shutdown of the server
 		return
 	}
 
-	log.Warn("Graceful shutdown is not supported for the provided server instance")
+	grpcLog.Warn("Graceful shutdown is not supported for the provided server instance")
 }
@@ -1,12 +1,16 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"proto-snapshot-server/config"
 	"syscall"
 	"testing"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -47,3 +51,41 @@ func TestGracefulShutdown(t *testing.T) {
 	// Verify that the server has shut down gracefully
 	assert.True(t, true, "Server shut down gracefully")
 }
+
+func TestWriteFrameSkipsWriteAfterContextDone(t *testing.T) {
+	useSettings(t, &config.Settings{SubmissionTimeout: time.Second, StreamWriteTimeout: time.Second})
+	stream := newFakeStream("")
+	pool := useTestPool(t, false, stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := writeFrame(ctx, []byte("payload"), "test frame", log.NewEntry(log.StandardLogger()))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, stream.written.Len(), "nothing may be written once the caller gave up")
+	assert.Equal(t, 0, len(pool.reqQueue), "slot must be released")
+	assert.Equal(t, 1, pool.Stats().Idle, "the unused stream goes back to the pool")
+
+	_, err = writeFrame(context.Background(), []byte("payload"), "test frame", log.NewEntry(log.StandardLogger()))
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", stream.written.String())
+}
+
+func TestWriteWithRetryStopsAfterMaxWriteRetries(t *testing.T) {
+	useSettings(t, &config.Settings{MaxWriteRetries: 2, SubmissionTimeout: 5 * time.Second})
+	s := &server{writePermits: newWritePermitScheduler(1, 10, 0, [laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1})}
+
+	attempts := 0
+	err := s.writeWithRetry(context.Background(), laneCurrent, func() error {
+		attempts++
+		return errors.New("request queue full")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts, "one attempt and MaxWriteRetries retries")
+}
+
+// useSettings installs settings for the duration of the test
+func useSettings(t *testing.T, settings *config.Settings) {
+	previous := config.Current()
+	config.Set(settings)
+	t.Cleanup(func() { config.Set(previous) })
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// submissionLane separates submissions by urgency for admission scheduling
+type submissionLane int
+
+const (
+	laneCurrent    submissionLane = iota // Submissions for the latest epoch seen
+	laneLate                             // Submissions for older epochs
+	laneSimulation                       // Simulation submissions (epoch 0)
+	laneUrgent                           // Submissions close to their deadline block, served first
+	laneCount
+)
+
+func (l submissionLane) String() string {
+	switch l {
+	case laneCurrent:
+		return "current"
+	case laneLate:
+		return "late"
+	case laneSimulation:
+		return "simulation"
+	case laneUrgent:
+		return "urgent"
+	default:
+		return "unknown"
+	}
+}
+
+// outranks reports whether l is more urgent than other: the urgent lane
+// first, then current, late and simulation
+func (l submissionLane) outranks(other submissionLane) bool {
+	if l == laneUrgent || other == laneUrgent {
+		return l == laneUrgent && other != laneUrgent
+	}
+	return l < other
+}
+
+// laneWeights returns the configured scheduling weight of each lane. The
+// urgent lane has no weight; it always goes first.
+func laneWeights() [laneCount]int {
+	return [laneCount]int{
+		laneCurrent:    config.Current().LaneWeightCurrent,
+		laneLate:       config.Current().LaneWeightLate,
+		laneSimulation: config.Current().LaneWeightSimulation,
+	}
+}
+
+// laneFor classifies a submission against its deadline and the latest epoch
+// seen so far, which the epoch tracker has already updated for it
+func (s *server) laneFor(request *pkgs.Request) submissionLane {
+	if request.EpochId == 0 {
+		return laneSimulation
+	}
+	latest := s.epochs.latestEpoch()
+	if nearDeadline(request) {
+		return laneUrgent
+	}
+	if request.EpochId < latest {
+		return laneLate
+	}
+	return laneCurrent
+}
+
+// lanePicker chooses between non-empty lanes with smooth weighted round-robin,
+// so a lane with weight 8 is served 8 times as often as one with weight 1
+// without starving the lighter lane
+type lanePicker struct {
+	weights [laneCount]int
+	current [laneCount]int
+}
+
+func newLanePicker(weights [laneCount]int) *lanePicker {
+	for i, w := range weights {
+		if w <= 0 {
+			weights[i] = 1
+		}
+	}
+	return &lanePicker{weights: weights}
+}
+
+// pick returns the lane to serve among those for which eligible is true:
+// the urgent lane whenever it is eligible, otherwise the next weighted lane
+func (p *lanePicker) pick(eligible func(submissionLane) bool) (submissionLane, bool) {
+	if eligible(laneUrgent) {
+		return laneUrgent, true
+	}
+	return p.next(eligible)
+}
+
+// next returns the weighted lane to serve among those for which eligible is
+// true
+func (p *lanePicker) next(eligible func(submissionLane) bool) (submissionLane, bool) {
+	total := 0
+	best := submissionLane(-1)
+	for l := submissionLane(0); l < laneUrgent; l++ {
+		if !eligible(l) {
+			continue
+		}
+		p.current[l] += p.weights[l]
+		total += p.weights[l]
+		if best < 0 || p.current[l] > p.current[best] {
+			best = l
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	p.current[best] -= total
+	return best, true
+}
+
+// permitWaiter is a caller queued for a write permit
+type permitWaiter struct {
+	lane  submissionLane
+	ready chan struct{}
+}
+
+// writePermitScheduler bounds concurrent stream writes like a semaphore, but
+// hands out free permits to waiting callers by lane weight rather than
+// arrival order. Simulation traffic can additionally be capped.
+type writePermitScheduler struct {
+	mu            sync.Mutex
+	idle          *sync.Cond
+	limit         int
+	inFlight      int
+	laneInFlight  [laneCount]int
+	simulationCap int // 0 means uncapped
+	queueSize     int // Per-lane waiting limit
+	waiting       [laneCount][]*permitWaiter
+	picker        *lanePicker
+	closed        bool
+}
+
+func newWritePermitScheduler(limit, queueSize, simulationCap int, weights [laneCount]int) *writePermitScheduler {
+	s := &writePermitScheduler{
+		limit:         limit,
+		simulationCap: simulationCap,
+		queueSize:     queueSize,
+		picker:        newLanePicker(weights),
+	}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// acquire blocks until a write permit is granted for lane or ctx is done
+func (s *writePermitScheduler) acquire(ctx context.Context, lane submissionLane) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return status.Error(codes.Unavailable, "collector is shutting down")
+	}
+	if len(s.waiting[lane]) >= s.queueSize {
+		s.mu.Unlock()
+		return status.Errorf(codes.ResourceExhausted, "%s lane queue full - try again later", lane)
+	}
+	w := &permitWaiter{lane: lane, ready: make(chan struct{})}
+	s.waiting[lane] = append(s.waiting[lane], w)
+	s.dispatchLocked()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Granted while we gave up - hand the permit on
+			s.releaseLocked(lane)
+		default:
+			s.removeLocked(w)
+		}
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// release returns a permit granted for lane
+func (s *writePermitScheduler) release(lane submissionLane) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked(lane)
+}
+
+func (s *writePermitScheduler) releaseLocked(lane submissionLane) {
+	s.inFlight--
+	s.laneInFlight[lane]--
+	s.dispatchLocked()
+	if s.inFlight == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+func (s *writePermitScheduler) dispatchLocked() {
+	for s.inFlight < s.limit {
+		lane, ok := s.picker.pick(func(l submissionLane) bool {
+			if len(s.waiting[l]) == 0 {
+				return false
+			}
+			return l != laneSimulation || s.simulationCap <= 0 || s.laneInFlight[l] < s.simulationCap
+		})
+		if !ok {
+			return
+		}
+		w := s.waiting[lane][0]
+		s.waiting[lane] = s.waiting[lane][1:]
+		s.inFlight++
+		s.laneInFlight[lane]++
+		close(w.ready)
+	}
+}
+
+func (s *writePermitScheduler) removeLocked(w *permitWaiter) {
+	queue := s.waiting[w.lane]
+	for i, candidate := range queue {
+		if candidate == w {
+			s.waiting[w.lane] = append(queue[:i], queue[i+1:]...)
+			s.idle.Broadcast()
+			return
+		}
+	}
+}
+
+// setLimit changes the number of concurrent writes allowed. Lowering it lets
+// in-flight writes finish; no new permits are granted until below the limit.
+func (s *writePermitScheduler) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit = limit
+	s.dispatchLocked()
+}
+
+// setQueueSize changes how many writes may wait per lane. Waiters beyond a
+// lowered size keep their place; only new arrivals are rejected.
+func (s *writePermitScheduler) setQueueSize(queueSize int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queueSize = queueSize
+}
+
+// permitStats is a point-in-time view of write permit usage
+type permitStats struct {
+	Limit    int
+	InFlight int
+	Waiting  [laneCount]int
+}
+
+func (s *writePermitScheduler) stats() permitStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := permitStats{Limit: s.limit, InFlight: s.inFlight}
+	for l, queue := range s.waiting {
+		stats.Waiting[l] = len(queue)
+	}
+	return stats
+}
+
+// close rejects new callers and waits for every granted permit to be released
+func (s *writePermitScheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for s.inFlight > 0 || s.waitingLocked() > 0 {
+		s.idle.Wait()
+	}
+}
+
+func (s *writePermitScheduler) waitingLocked() int {
+	n := 0
+	for _, queue := range s.waiting {
+		n += len(queue)
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanePickerHonoursWeights(t *testing.T) {
+	picker := newLanePicker([laneCount]int{laneCurrent: 8, laneLate: 3, laneSimulation: 1})
+	all := func(submissionLane) bool { return true }
+
+	var served [laneCount]int
+	for i := 0; i < 120; i++ {
+		lane, ok := picker.next(all)
+		assert.True(t, ok)
+		served[lane]++
+	}
+
+	assert.Equal(t, 80, served[laneCurrent])
+	assert.Equal(t, 30, served[laneLate])
+	assert.Equal(t, 10, served[laneSimulation])
+}
+
+func TestLanePickerSkipsIneligibleLanes(t *testing.T) {
+	picker := newLanePicker([laneCount]int{laneCurrent: 8, laneLate: 3, laneSimulation: 1})
+
+	lane, ok := picker.next(func(l submissionLane) bool { return l == laneSimulation })
+	assert.True(t, ok)
+	assert.Equal(t, laneSimulation, lane)
+
+	_, ok = picker.next(func(submissionLane) bool { return false })
+	assert.False(t, ok)
+}
+
+func TestWritePermitSchedulerPrefersCurrentEpoch(t *testing.T) {
+	scheduler := newWritePermitScheduler(1, 10, 0, [laneCount]int{laneCurrent: 100, laneLate: 1, laneSimulation: 1})
+	ctx := context.Background()
+
+	// Hold the only permit so later callers have to queue
+	assert.NoError(t, scheduler.acquire(ctx, laneCurrent))
+
+	granted := make(chan submissionLane, 2)
+	for _, lane := range []submissionLane{laneSimulation, laneCurrent} {
+		lane := lane
+		go func() {
+			if err := scheduler.acquire(ctx, lane); err == nil {
+				granted <- lane
+			}
+		}()
+		waitForWaiters(t, scheduler, lane, 1)
+	}
+
+	scheduler.release(laneCurrent)
+	assert.Equal(t, laneCurrent, <-granted)
+	scheduler.release(laneCurrent)
+	assert.Equal(t, laneSimulation, <-granted)
+	scheduler.release(laneSimulation)
+}
+
+func TestWritePermitSchedulerCapsSimulation(t *testing.T) {
+	scheduler := newWritePermitScheduler(4, 10, 1, [laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1})
+	assert.NoError(t, scheduler.acquire(context.Background(), laneSimulation))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, scheduler.acquire(ctx, laneSimulation))
+
+	// Other lanes are unaffected by the simulation cap
+	assert.NoError(t, scheduler.acquire(context.Background(), laneLate))
+
+	scheduler.mu.Lock()
+	assert.Equal(t, 0, len(scheduler.waiting[laneSimulation]), "abandoned waiter should be removed")
+	scheduler.mu.Unlock()
+}
+
+func waitForWaiters(t *testing.T, scheduler *writePermitScheduler, lane submissionLane, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		scheduler.mu.Lock()
+		waiting := len(scheduler.waiting[lane])
+		scheduler.mu.Unlock()
+		if waiting >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d %s waiters", n, lane)
+}
@@ -12,6 +12,7 @@ import (
 	circuitv2 "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
 
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -21,17 +22,20 @@ import (
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	ma "github.com/multiformats/go-multiaddr"
-	log "github.com/sirupsen/logrus"
 )
 
 var (
-	SequencerHostConn    host.Host
-	SequencerID          peer.ID
-	sequencerMu          sync.RWMutex
-	ConnManager          *connmgr.BasicConnMgr
-	TcpAddr              ma.Multiaddr
-	rm                   network.ResourceManager
-	connectionRefreshing atomic.Bool
+	SequencerHostConn     host.Host
+	connectivitySub       event.Subscription // Event bus subscription of SequencerHostConn
+	SequencerID           peer.ID
+	SequencerMaddr        string
+	lastConnectionRefresh time.Time
+	sequencerMu           sync.RWMutex
+	refreshMu             sync.Mutex
+	ConnManager           *connmgr.BasicConnMgr
+	TcpAddr               ma.Multiaddr
+	rm                    network.ResourceManager
+	connectionRefreshing  atomic.Bool
 )
 
 // Thread-safe getter for connection state
@@ -52,16 +56,16 @@ func ConnectToSequencerP2P(relayers []Relayer, p2pHost host.Host) bool {
 		relayerInfo, _ := peer.AddrInfoFromP2pAddr(relayerMA)
 
 		if reservation, err := circuitv2.Reserve(context.Background(), p2pHost, *relayerInfo); err != nil {
-			log.Fatalf("Failed to request reservation with relay: %v", err)
+			connLog.Fatalf("Failed to request reservation with relay: %v", err)
 		} else {
 			fmt.Println("Reservation with relay successful", reservation.Expiration, reservation.LimitDuration)
 		}
 
-		sequencerAddr, err := ma.NewMultiaddr(fmt.Sprintf("%s/p2p-circuit/p2p/%s", relayer.Maddr, config.SettingsObj.SequencerID))
+		sequencerAddr, err := ma.NewMultiaddr(fmt.Sprintf("%s/p2p-circuit/p2p/%s", relayer.Maddr, config.Current().SequencerID))
 		if err != nil {
-			log.Debugln(err.Error())
+			connLog.Debugln(err.Error())
 		}
-		log.Debugln("Connecting to Sequencer: ", sequencerAddr.String())
+		connLog.Debugln("Connecting to Sequencer: ", sequencerAddr.String())
 
 		isConnected := AddPeerConnection(context.Background(), p2pHost, sequencerAddr.String())
 		if isConnected {
@@ -107,7 +111,7 @@ func CreateLibP2pHost() error {
 	rm, err = rcmgr.NewResourceManager(limiter, rcmgr.WithMetricsDisabled())
 
 	if err != nil {
-		log.Debugln("Error instantiating resource manager: ", err.Error())
+		connLog.Debugln("Error instantiating resource manager: ", err.Error())
 		return err
 	}
 
@@ -126,7 +130,7 @@ func CreateLibP2pHost() error {
 		libp2p.Muxer(yamux.ID, yamux.DefaultTransport))
 
 	if err != nil {
-		log.Debugln("Error instantiating libp2p host: ", err.Error())
+		connLog.Debugln("Error instantiating libp2p host: ", err.Error())
 		return err
 	}
 	return nil
@@ -140,8 +144,9 @@ func EstablishSequencerConnection() error {
 
 	// Clear existing connection if any
 	if SequencerHostConn != nil {
+		stopWatchingConnectivity()
 		if err := SequencerHostConn.Close(); err != nil {
-			log.Warnf("Error closing existing connection: %v", err)
+			connLog.Warnf("Error closing existing connection: %v", err)
 		}
 		// Important: Signal that connection is being reset
 		// This should trigger cleanup of existing stream pool
@@ -153,15 +158,17 @@ func EstablishSequencerConnection() error {
 	if err := CreateLibP2pHost(); err != nil {
 		return fmt.Errorf("failed to create libp2p host: %w", err)
 	}
+	watchConnectivity(SequencerHostConn)
 
 	// No need to reassign SequencerHostConn as it's already set in CreateLibP2pHost()
 
 	// 2. Get sequencer info
-	sequencer, err := fetchSequencer(
-		"https://raw.githubusercontent.com/PowerLoom/snapshotter-lite-local-collector/feat/trusted-relayers/sequencers.json",
-		config.SettingsObj.DataMarketAddress,
-	)
+	sequencer, err := ResolveSequencer()
 	if err != nil {
+		reportIssue(IssueSequencerListFetchFailure, nil, err, IssueDetails{
+			"sequencersListUrl": config.Current().SequencersListUrl,
+			"dataMarket":        config.Current().DataMarketAddress,
+		})
 		return fmt.Errorf("failed to fetch sequencer info: %w", err)
 	}
 
@@ -187,97 +194,134 @@ func EstablishSequencerConnection() error {
 	defer cancel()
 
 	if err := SequencerHostConn.Connect(ctx, *sequencerInfo); err != nil {
+		reportIssue(IssueSequencerDialFailure, nil, err, IssueDetails{
+			"sequencerId":    SequencerID.String(),
+			"sequencerMaddr": sequencer.Maddr,
+		})
 		return fmt.Errorf("failed to connect to sequencer: %w", err)
 	}
 
-	log.Infof("Successfully connected to Sequencer: %s with ID: %s", sequencer.Maddr, SequencerID.String())
+	SequencerMaddr = sequencer.Maddr
+	lastConnectionRefresh = time.Now()
+	logSequencerConns(SequencerHostConn, SequencerID)
+
+	connLog.Infof("Successfully connected to Sequencer: %s with ID: %s", sequencer.Maddr, SequencerID.String())
 	return nil
 }
 
+// StartConnectionRefreshLoop refreshes the sequencer connection periodically.
+// The interval is re-read each cycle so config reloads take effect.
 func StartConnectionRefreshLoop(ctx context.Context) {
-	ticker := time.NewTicker(config.SettingsObj.ConnectionRefreshInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(config.Current().ConnectionRefreshInterval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			log.Info("🔄 Starting periodic connection refresh cycle")
+		case <-timer.C:
+			timer.Reset(config.Current().ConnectionRefreshInterval)
+			connLog.Info("🔄 Starting periodic connection refresh cycle")
+			if err := RefreshSequencerConnection(); err != nil {
+				connLog.Errorf("❌ Periodic connection refresh failed: %v", err)
+			}
+		}
+	}
+}
 
-			connectionRefreshing.Store(true)
-			log.Info("🚫 Connection refresh state activated - new streams will wait")
+// RefreshSequencerConnection waits for in-flight stream requests, reconnects
+// to the sequencer and rebuilds the stream pool. Only one refresh cycle runs
+// at a time.
+func RefreshSequencerConnection() error {
+	if !refreshMu.TryLock() {
+		return fmt.Errorf("connection refresh already in progress")
+	}
+	defer refreshMu.Unlock()
 
-			pool := GetLibp2pStreamPool()
-			if pool == nil {
-				log.Error("❌ Stream pool not available for refresh")
-				connectionRefreshing.Store(false)
-				continue
-			}
+	refreshStart := time.Now()
 
-			// Wait for in-flight requests with exponential backoff
-			b := backoff.NewExponentialBackOff()
-			b.MaxElapsedTime = 30 * time.Second
-			b.InitialInterval = 100 * time.Millisecond
-
-			log.Info("⏳ Waiting for in-flight requests to complete")
-			err := backoff.Retry(func() error {
-				filled := 0
-				// First try to get all slots to check for active requests
-				for i := 0; i < cap(pool.reqQueue); i++ {
-					select {
-					case pool.reqQueue <- &reqSlot{
-						id:        fmt.Sprintf("refresh-check-%d", i),
-						createdAt: time.Now(),
-					}:
-						filled++
-					default:
-						// If we can't fill the queue, there are active requests
-						activeRequests := cap(pool.reqQueue) - filled
-						log.Infof("🔴 Found %d active requests", activeRequests)
-
-						// Return all the tokens we just acquired
-						for j := 0; j < filled; j++ {
-							<-pool.reqQueue
-						}
-
-						// Wait for active requests to complete
-						time.Sleep(1 * time.Second)
-						return fmt.Errorf("requests still in flight")
-					}
-				}
+	connectionRefreshing.Store(true)
+	connLog.Info("🚫 Connection refresh state activated - new streams will wait")
 
-				// If we got here, we successfully filled the queue
-				log.Info("✅ All request slots available - proceeding with refresh")
+	pool := GetLibp2pStreamPool()
+	if pool == nil {
+		connectionRefreshing.Store(false)
+		connectionRefreshes.WithLabelValues("pool_unavailable").Inc()
+		return fmt.Errorf("stream pool not available for refresh")
+	}
 
-				// Return all tokens before proceeding
-				for i := 0; i < filled; i++ {
+	// Wait for in-flight requests with exponential backoff
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 30 * time.Second
+	b.InitialInterval = 100 * time.Millisecond
+
+	connLog.Info("⏳ Waiting for in-flight requests to complete")
+	err := backoff.Retry(func() error {
+		filled := 0
+		// First try to get all slots to check for active requests
+		for i := 0; i < cap(pool.reqQueue); i++ {
+			select {
+			case pool.reqQueue <- &reqSlot{
+				id:        fmt.Sprintf("refresh-check-%d", i),
+				createdAt: time.Now(),
+			}:
+				filled++
+			default:
+				// If we can't fill the queue, there are active requests
+				activeRequests := cap(pool.reqQueue) - filled
+				connLog.Infof("🔴 Found %d active requests", activeRequests)
+
+				// Return all the tokens we just acquired
+				for j := 0; j < filled; j++ {
 					<-pool.reqQueue
 				}
-				return nil
-			}, b)
-
-			if err != nil {
-				log.Warnf("⚠️ Proceeding with refresh despite active requests: %v", err)
-				// Give a small grace period for any remaining requests
-				time.Sleep(2 * time.Second)
-			}
 
-			log.Info("🔌 Refreshing connection to sequencer")
-			if err := EstablishSequencerConnection(); err != nil {
-				log.Errorf("❌ Failed to refresh connection: %v", err)
-				connectionRefreshing.Store(false)
-				continue
+				// Wait for active requests to complete
+				time.Sleep(1 * time.Second)
+				return fmt.Errorf("requests still in flight")
 			}
-			log.Info("✅ New connection established successfully")
+		}
 
-			log.Info("🏊 Rebuilding stream pool")
-			if err := RebuildStreamPool(); err != nil {
-				log.Errorf("❌ Failed to rebuild stream pool: %v", err)
-			}
+		// If we got here, we successfully filled the queue
+		connLog.Info("✅ All request slots available - proceeding with refresh")
 
-			connectionRefreshing.Store(false)
-			log.Info("✅ Connection refresh cycle completed successfully")
+		// Return all tokens before proceeding
+		for i := 0; i < filled; i++ {
+			<-pool.reqQueue
 		}
+		return nil
+	}, b)
+
+	if err != nil {
+		connLog.Warnf("⚠️ Proceeding with refresh despite active requests: %v", err)
+		// Give a small grace period for any remaining requests
+		time.Sleep(2 * time.Second)
+	}
+
+	connLog.Info("🔌 Refreshing connection to sequencer")
+	if err := EstablishSequencerConnection(); err != nil {
+		connectionRefreshing.Store(false)
+		connectionRefreshes.WithLabelValues("connect_failed").Inc()
+		connectionRefreshDuration.Observe(time.Since(refreshStart).Seconds())
+		reportIssue(IssueConnectionRefreshFailure, nil, err, IssueDetails{"stage": "connect"})
+		return fmt.Errorf("failed to refresh connection: %w", err)
 	}
+	connLog.Info("✅ New connection established successfully")
+
+	connLog.Info("🏊 Rebuilding stream pool")
+	result := "success"
+	rebuildErr := RebuildStreamPool()
+	if rebuildErr != nil {
+		result = "rebuild_failed"
+	}
+
+	connectionRefreshing.Store(false)
+	connectionRefreshes.WithLabelValues(result).Inc()
+	connectionRefreshDuration.Observe(time.Since(refreshStart).Seconds())
+	if rebuildErr != nil {
+		reportIssue(IssueConnectionRefreshFailure, nil, rebuildErr, IssueDetails{"stage": "rebuild_pool"})
+		return fmt.Errorf("failed to rebuild stream pool: %w", rebuildErr)
+	}
+	connLog.Info("✅ Connection refresh cycle completed successfully")
+	return nil
 }
@@ -35,10 +35,10 @@ func TestConnectToSequencerP2P(t *testing.T) {
 	defer ts.Close()
 
 	// Initialize the configuration settings
-	config.SettingsObj = &config.Settings{
+	useSettings(t, &config.Settings{
 		TrustedRelayersListUrl: ts.URL,
 		SequencerID:            "QmdJbNsbHpFseUPKC9vLt4vMsfdxA4dyHPzsAWuzYz3Yxx",
-	}
+	})
 
 	host, err := libp2p.New()
 	if err != nil {
@@ -57,5 +57,5 @@ func TestConnectToSequencerP2P(t *testing.T) {
 
 	log.Println("Connected to peer 1: ", host.Network().ConnsToPeer(peer.ID(relayers[0].ID)))
 	log.Println("Connected to peer 2: ", host.Network().ConnsToPeer(peer.ID(relayers[0].ID)))
-	log.Println("Connected to peer 3: ", host.Network().ConnsToPeer(peer.ID(config.SettingsObj.SequencerID)))
+	log.Println("Connected to peer 3: ", host.Network().ConnsToPeer(peer.ID(config.Current().SequencerID)))
 }
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var reloadMu sync.Mutex
+
+// liveSettings are read from config.Current() on every use, so publishing the
+// settings is enough for them to take effect
+var liveSettings = map[string]bool{
+	"stream_health_check_timeout": true,
+	"stream_write_timeout":        true,
+	"max_write_retries":           true,
+	"submission_timeout":          true,
+	"ephemeral_read_response":     true,
+	"ephemeral_response_timeout":  true,
+	"connection_refresh_interval": true,
+	"log_level_overrides_file":    true,
+	"log_level_override_ttl":      true,
+	"heartbeat_enabled":           true,
+	"heartbeat_interval":          true,
+	"epoch_quiet_period":          true,
+	"epoch_submission_deadline":   true,
+	"epoch_summary_reporting":     true,
+	"deadline_action":             true,
+	"validation_action":           true,
+	"max_submission_bytes":        true,
+	"deadline_urgent_blocks":      true,
+}
+
+// reloadAppliers push a changed setting into the component that holds it
+var reloadAppliers = map[string]func(s *server, settings *config.Settings) error{
+	"log_level": func(_ *server, settings *config.Settings) error {
+		lvl, err := log.ParseLevel(settings.LogLevel)
+		if err != nil {
+			return err
+		}
+		helpers.SetBaseLogLevel(lvl)
+		return nil
+	},
+	"max_concurrent_writes": func(s *server, settings *config.Settings) error {
+		return s.SetMaxConcurrentWrites(settings.MaxConcurrentWrites)
+	},
+	"max_stream_pool_size": func(_ *server, settings *config.Settings) error {
+		return ResizeStreamPool(settings.MaxStreamPoolSize)
+	},
+	"lane_queue_size": func(s *server, settings *config.Settings) error {
+		s.writePermits.setQueueSize(settings.LaneQueueSize)
+		return nil
+	},
+	"submission_queue_size": func(s *server, settings *config.Settings) error {
+		if s.dispatcher != nil {
+			s.dispatcher.setQueueSize(settings.SubmissionQueueSize)
+		}
+		return nil
+	},
+	"epoch_metrics_window": func(s *server, settings *config.Settings) error {
+		s.epochs.setWindow(settings.EpochMetricsWindow)
+		return nil
+	},
+	"log_success_sample_every": func(s *server, settings *config.Settings) error {
+		s.successLogs.SetEvery(settings.LogSuccessSampleEvery)
+		return nil
+	},
+	"epoch_max_advance":        applyEpochJumpGuard,
+	"epoch_jump_confirmations": applyEpochJumpGuard,
+	"epoch_anchor_timeout":     applyEpochJumpGuard,
+}
+
+// reloadComponents are restarted when any of their settings change. Each is
+// restarted once per reload, however many of its settings changed.
+var reloadComponents = []struct {
+	name   string
+	fields []string
+	apply  func(s *server, settings *config.Settings) error
+}{
+	{
+		name:   "chain_head",
+		fields: []string{"chain_head_provider", "chain_rpc_url", "chain_poll_interval", "chain_static_block"},
+		apply:  applyChainHead,
+	},
+	{
+		// The signer account is checked against the signing key on start
+		name: "reporting",
+		fields: []string{
+			"powerloom_reporting_url", "reporting_timeout", "reporting_queue_size",
+			"reporting_batch_size", "reporting_batch_window", "reporting_max_retry_time",
+			"reporting_rate_limit", "reporting_dedup_window", "reporting_spool_file",
+			"reporting_spool_max_bytes", "reporting_signing_key", "signer_account_address",
+			"webhooks",
+		},
+		apply: applyReporting,
+	},
+}
+
+// reloadComponent returns the index of the component restarted when field
+// changes, or -1
+func reloadComponent(field string) int {
+	for i, component := range reloadComponents {
+		for _, f := range component.fields {
+			if f == field {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// reconnectSettings change which sequencer the collector talks to, so they
+// trigger a connection refresh
+var reconnectSettings = map[string]bool{
+	"data_market_address":       true,
+	"sequencers_list_url":       true,
+	"trusted_relayers_list_url": true,
+	"sequencer_id":              true,
+}
+
+// ReloadConfig re-reads the configuration from path and the environment and
+// applies what can change without a restart. Settings that need a restart
+// keep their running values. Invalid configuration is rejected as a whole.
+func ReloadConfig(s pkgs.SubmissionServer, path string) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	srv, ok := s.(*server)
+	if !ok {
+		return fmt.Errorf("config reload is not supported for the provided server instance")
+	}
+
+	next, err := config.Load(path)
+	if err != nil {
+		return fmt.Errorf("config reload rejected: %w", err)
+	}
+
+	current := config.Current()
+	changes := config.Diff(current, next)
+	if len(changes) == 0 {
+		log.Info("🔧 Config reloaded, nothing changed")
+		return nil
+	}
+
+	var restart []string
+	var applied []config.Change
+	restartComponents := make([]bool, len(reloadComponents))
+	reconnect := false
+	for _, change := range changes {
+		switch {
+		case reconnectSettings[change.Field]:
+			reconnect = true
+			log.Infof("🔧 Config change (reconnect): %s", change)
+		case liveSettings[change.Field]:
+			log.Infof("🔧 Config change: %s", change)
+		case reloadAppliers[change.Field] != nil:
+			applied = append(applied, change)
+			log.Infof("🔧 Config change: %s", change)
+		case reloadComponent(change.Field) >= 0:
+			restartComponents[reloadComponent(change.Field)] = true
+			log.Infof("🔧 Config change: %s", change)
+		default:
+			restart = append(restart, change.Field)
+			log.Warnf("⚠️ Config change ignored until restart: %s", change)
+		}
+	}
+
+	next.Keep(current, restart...)
+	config.Set(next)
+
+	var applyErrs []error
+	for _, change := range applied {
+		if err := reloadAppliers[change.Field](srv, next); err != nil {
+			applyErrs = append(applyErrs, fmt.Errorf("%s: %w", change.Field, err))
+		}
+	}
+	for i, component := range reloadComponents {
+		if !restartComponents[i] {
+			continue
+		}
+		if err := component.apply(srv, next); err != nil {
+			applyErrs = append(applyErrs, fmt.Errorf("%s: %w", component.name, err))
+		}
+	}
+	if len(applyErrs) > 0 {
+		log.Errorf("❌ Some config changes could not be applied: %v", applyErrs)
+	}
+
+	if reconnect {
+		go func() {
+			log.Info("🔄 Refreshing sequencer connection after config reload")
+			if err := RefreshSequencerConnection(); err != nil {
+				log.Errorf("❌ Connection refresh after config reload failed: %v", err)
+			}
+		}()
+	}
+
+	log.Infof("✅ Config reloaded: %d applied, %d pending restart", len(changes)-len(restart), len(restart))
+	return nil
+}
+
+// applyEpochJumpGuard updates how the epoch tracker treats far epoch IDs
+func applyEpochJumpGuard(s *server, settings *config.Settings) error {
+	s.epochs.setJumpGuard(settings.EpochMaxAdvance, settings.EpochJumpConfirmations, settings.EpochAnchorTimeout)
+	return nil
+}
+
+// applyChainHead restarts the chain head provider with the new settings
+func applyChainHead(_ *server, settings *config.Settings) error {
+	return InitChainHead(settings)
+}
+
+// applyReporting restarts the reporting service and webhooks, which share
+// the reporting limits, with the new settings. The reporting service is
+// turned off when no reporting URL is configured.
+func applyReporting(_ *server, settings *config.Settings) error {
+	if settings.PowerloomReportingUrl == "" {
+		StopReportingService()
+	} else {
+		InitializeReportingService(settings)
+	}
+	return InitializeWebhooks(settings)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"proto-snapshot-server/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadRestartsEachComponentOnce(t *testing.T) {
+	t.Setenv("DATA_MARKET_CONTRACT", "0x21cb57C1f2352ad215a463DD867b838749CD3b8f")
+	current, err := config.Load("")
+	assert.NoError(t, err)
+	useSettings(t, current)
+
+	restarts := map[string]int{}
+	previous := reloadComponents
+	t.Cleanup(func() { reloadComponents = previous })
+	reloadComponents = append(reloadComponents[:0:0], previous...)
+	for i := range reloadComponents {
+		name := reloadComponents[i].name
+		reloadComponents[i].apply = func(*server, *config.Settings) error {
+			restarts[name]++
+			return nil
+		}
+	}
+
+	t.Setenv("REPORTING_QUEUE_SIZE", "50")
+	t.Setenv("REPORTING_BATCH_SIZE", "5")
+	t.Setenv("REPORTING_RATE_LIMIT", "20")
+	t.Setenv("SIGNER_ACCOUNT_ADDRESS", "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+	assert.NoError(t, ReloadConfig(&server{}, ""))
+
+	assert.Equal(t, map[string]int{"reporting": 1}, restarts)
+	assert.Equal(t, 50, config.Current().ReportingQueueSize)
+}
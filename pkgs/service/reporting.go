@@ -2,81 +2,521 @@ package service
 
 import (
 	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	log "github.com/sirupsen/logrus"
+	"io"
 	"net/http"
 	"proto-snapshot-server/config"
 	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// IssueType classifies the failures reported to the Powerloom reporting
+// service
+type IssueType string
+
+const (
+	IssueRelayerConnectionFailure  IssueType = "RELAYER_CONNECTION_FAILURE"
+	IssueSequencerDialFailure      IssueType = "SEQUENCER_DIAL_FAILURE"
+	IssueSequencerListFetchFailure IssueType = "SEQUENCER_LIST_FETCH_FAILURE"
+	IssueStreamPoolExhausted       IssueType = "STREAM_POOL_EXHAUSTED"
+	IssueStreamWriteFailure        IssueType = "STREAM_WRITE_FAILURE"
+	IssueConnectionRefreshFailure  IssueType = "CONNECTION_REFRESH_FAILURE"
+	IssueSignatureMismatch         IssueType = "SIGNATURE_MISMATCH"
+	IssueProjectsMissing           IssueType = "PROJECTS_MISSING"
+	IssueDeadlinePassed            IssueType = "SUBMISSION_PAST_DEADLINE"
+	IssueInvalidSubmission         IssueType = "INVALID_SUBMISSION"
 )
 
-var ReportingInstance *ReportingService
+// Severity ranks issue types so webhooks can be limited to the serious ones
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	return config.Severities[s]
+}
+
+// parseSeverity maps a configured severity name to its level; empty and
+// unknown names mean info
+func parseSeverity(name string) Severity {
+	for i, severity := range config.Severities {
+		if strings.EqualFold(name, severity) {
+			return Severity(i)
+		}
+	}
+	return SeverityInfo
+}
+
+var issueSeverities = map[IssueType]Severity{
+	IssueRelayerConnectionFailure:  SeverityCritical,
+	IssueSequencerDialFailure:      SeverityCritical,
+	IssueSequencerListFetchFailure: SeverityCritical,
+	IssueConnectionRefreshFailure:  SeverityCritical,
+	IssueStreamPoolExhausted:       SeverityWarning,
+	IssueStreamWriteFailure:        SeverityWarning,
+	IssueSignatureMismatch:         SeverityWarning,
+	IssueProjectsMissing:           SeverityWarning,
+	IssueDeadlinePassed:            SeverityInfo,
+	IssueInvalidSubmission:         SeverityWarning,
+}
+
+// Severity returns how serious issues of this type are
+func (t IssueType) Severity() Severity {
+	if severity, ok := issueSeverities[t]; ok {
+		return severity
+	}
+	return SeverityWarning
+}
+
+// reportingInstance is the running reporting service; nil when reporting is
+// off. It is replaced on config reload while submissions are reporting.
+var reportingInstance atomic.Pointer[ReportingService]
 
+// ReportingService delivers issues from a background worker. Issues are
+// queued without blocking the caller, collected into batches, posted one by
+// one to /reportIssue with retries and spooled to disk, when a spool file is
+// configured, while the reporting endpoint stays unreachable. Epoch summaries
+// are posted to /epochSummary by the same worker, once and without spooling.
 type ReportingService struct {
-	url    string
-	client *http.Client
+	url             string
+	heartbeatURL    string
+	epochSummaryURL string
+	client          *http.Client
+	opts            reportingOptions
+	signer          *helpers.EthereumSigner // nil when reports are unsigned
+
+	queue     chan SnapshotterIssue
+	summaries chan EpochSummary
+	limiter   *issueLimiter
+	spool     *issueSpool
+
+	mu       sync.RWMutex // Orders Report against Stop so no issue is queued after the final drain
+	stopped  bool
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
 }
+
+type reportingOptions struct {
+	queueSize     int
+	batchSize     int
+	batchWindow   time.Duration
+	maxRetryTime  time.Duration
+	ratePerMinute int
+	dedupWindow   time.Duration
+	spoolFile     string
+	spoolMaxBytes int
+}
+
+// How often spooled issues are retried while nothing new is being reported
+const spoolReplayInterval = time.Minute
+
+// Headers carrying the EIP-191 signature of a report body and the address
+// that made it
+const (
+	headerReportSignature = "X-Report-Signature"
+	headerReportSigner    = "X-Report-Signer"
+)
+
+// errReportRejected marks reports that will never be accepted, so they are
+// dropped rather than spooled
+var errReportRejected = errors.New("report rejected")
+
 type SnapshotterIssue struct {
-	InstanceID      string `json:"instanceID"`
-	IssueType       string `json:"issueType"`
-	ProjectID       string `json:"projectID"`
-	EpochID         string `json:"epochId"`
-	TimeOfReporting string `json:"timeOfReporting"`
-	Extra           string `json:"extra"`
-}
-
-func InitializeReportingService(url string, timeout time.Duration) {
-	ReportingInstance = &ReportingService{
-		url: url + "/reportIssue", client: &http.Client{Timeout: timeout},
-	}
-}
-
-// sendPostRequest sends a POST request to the specified URL
-func (s *ReportingService) SendFailureNotification(request *pkgs.Request, extraData string) {
-	var issue SnapshotterIssue
-	if request == nil {
-		issue = SnapshotterIssue{
-			InstanceID:      config.SettingsObj.SignerAccountAddress,
-			IssueType:       "RELAYER_CONNECTION_FAILURE", // Assume you have a constant or enum equivalent
-			ProjectID:       "",
-			EpochID:         "0", // Convert uint64 or similar to string
-			TimeOfReporting: strconv.FormatInt(time.Now().Unix(), 10),
-			Extra:           fmt.Sprintf(`{"issueDetails":"Error : %s"}`, extraData),
-		}
-	} else {
-		issue = SnapshotterIssue{
-			InstanceID:      config.SettingsObj.SignerAccountAddress,
-			IssueType:       "RELAYER_CONNECTION_FAILURE", // Assume you have a constant or enum equivalent
-			ProjectID:       request.ProjectId,
-			EpochID:         strconv.FormatUint(request.EpochId, 10), // Convert uint64 or similar to string
-			TimeOfReporting: strconv.FormatInt(time.Now().Unix(), 10),
-			Extra:           fmt.Sprintf(`{"issueDetails":"Error : %s"}`, extraData),
+	InstanceID      string    `json:"instanceID"`
+	IssueType       IssueType `json:"issueType"`
+	ProjectID       string    `json:"projectID"`
+	EpochID         string    `json:"epochId"`
+	TimeOfReporting string    `json:"timeOfReporting"`
+	Extra           string    `json:"extra"`
+
+	errorClass string // Low-cardinality kind of the error, used for dedup
+}
+
+// IssueDetails is the structured context of an issue, sent as the JSON
+// encoded Extra field
+type IssueDetails map[string]interface{}
+
+// InitializeReportingService starts reporting to settings.PowerloomReportingUrl,
+// stopping any service already running. Issues still queued on the old
+// service are spooled, if spooling is enabled, and picked up by the new one.
+func InitializeReportingService(settings *config.Settings) {
+	reporter := newReportingService(settings.PowerloomReportingUrl, settings.ReportingTimeout, reportingOptions{
+		queueSize:     settings.ReportingQueueSize,
+		batchSize:     settings.ReportingBatchSize,
+		batchWindow:   settings.ReportingBatchWindow,
+		maxRetryTime:  settings.ReportingMaxRetryTime,
+		ratePerMinute: settings.ReportingRateLimit,
+		dedupWindow:   settings.ReportingDedupWindow,
+		spoolFile:     settings.ReportingSpoolFile,
+		spoolMaxBytes: settings.ReportingSpoolMaxBytes,
+	})
+	if settings.ReportingSigningKey != "" {
+		signer, err := helpers.NewEthereumSigner(settings.ReportingSigningKey)
+		if err != nil {
+			reportingLog.Errorf("❌ Reports will be unsigned: %v", err)
+		} else {
+			if !strings.EqualFold(signer.Address(), settings.SignerAccountAddress) {
+				reportingLog.Warnf("⚠️ Reporting signing key belongs to %s, not signer account %s; reports will fail verification",
+					signer.Address(), settings.SignerAccountAddress)
+			}
+			reporter.signer = signer
 		}
 	}
+	if previous := reportingInstance.Swap(reporter); previous != nil {
+		previous.Stop()
+	}
+	go reporter.run()
+}
+
+// StopReportingService stops the running reporting service, if any
+func StopReportingService() {
+	if reporter := reportingInstance.Swap(nil); reporter != nil {
+		reporter.Stop()
+	}
+}
+
+func newReportingService(url string, timeout time.Duration, opts reportingOptions) *ReportingService {
+	return &ReportingService{
+		url:             url + "/reportIssue",
+		heartbeatURL:    url + "/heartbeat",
+		epochSummaryURL: url + "/epochSummary",
+		client:          &http.Client{Timeout: timeout},
+		opts:            opts,
+		queue:           make(chan SnapshotterIssue, opts.queueSize),
+		summaries:       make(chan EpochSummary, opts.queueSize),
+		limiter:         newIssueLimiter(opts.ratePerMinute, opts.dedupWindow),
+		spool:           &issueSpool{path: opts.spoolFile, maxBytes: int64(opts.spoolMaxBytes)},
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// newIssue builds an issue of the given type. request may be nil for
+// failures that are not tied to a submission; err, when set, is recorded as
+// the issueDetails entry of Extra.
+func newIssue(issueType IssueType, request *pkgs.Request, err error, details IssueDetails) SnapshotterIssue {
+	issue := SnapshotterIssue{
+		InstanceID:      config.Current().SignerAccountAddress,
+		IssueType:       issueType,
+		EpochID:         "0",
+		TimeOfReporting: strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	if request != nil {
+		issue.ProjectID = request.ProjectId
+		issue.EpochID = strconv.FormatUint(request.EpochId, 10)
+	}
 
-	jsonData, err := json.Marshal(issue)
+	extra := make(IssueDetails, len(details)+1)
+	for k, v := range details {
+		extra[k] = v
+	}
 	if err != nil {
-		log.Errorln("Unable to marshal notification for request: ", request.String())
+		extra["issueDetails"] = err.Error()
+		issue.errorClass = failureReason(err)
+	}
+	if data, marshalErr := json.Marshal(extra); marshalErr == nil {
+		issue.Extra = string(data)
+	} else {
+		issue.Extra = fmt.Sprintf(`{"marshalError":%q}`, marshalErr.Error())
+	}
+	return issue
+}
+
+// reportIssue queues an issue for the reporting service and the webhooks
+// that are enabled
+func reportIssue(issueType IssueType, request *pkgs.Request, err error, details IssueDetails) {
+	reporter, webhooks := reportingInstance.Load(), webhookInstance.Load()
+	if reporter == nil && webhooks == nil {
+		return
+	}
+	issue := newIssue(issueType, request, err, details)
+	if reporter != nil {
+		reporter.Report(issue)
+	}
+	if webhooks != nil {
+		webhooks.notify(issue)
+	}
+}
+
+// Report queues an issue without blocking. Issues over the rate limit,
+// duplicates of recent reports and issues arriving while the queue is full
+// or after Stop are dropped.
+func (s *ReportingService) Report(issue SnapshotterIssue) {
+	if ok, reason := s.limiter.allow(issue); !ok {
+		issueReports.WithLabelValues(string(issue.IssueType), reason).Inc()
+		reportingLog.Debugf("Dropped %s issue: %s", issue.IssueType, reason)
 		return
 	}
-	req, err := http.NewRequest("POST", s.url, bytes.NewBuffer(jsonData))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		issueReports.WithLabelValues(string(issue.IssueType), "stopped").Inc()
+		reportingLog.Debugf("Dropped %s issue: reporting service stopped", issue.IssueType)
+		return
+	}
+	select {
+	case s.queue <- issue:
+	default:
+		issueReports.WithLabelValues(string(issue.IssueType), "queue_full").Inc()
+		reportingLog.Warnf("🚫 Reporting queue full, dropped %s issue", issue.IssueType)
+	}
+}
+
+// ReportEpochSummary queues an epoch summary without blocking. Summaries
+// arriving while the queue is full or after Stop are dropped.
+func (s *ReportingService) ReportEpochSummary(summary EpochSummary) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return
+	}
+	select {
+	case s.summaries <- summary:
+	default:
+		reportingLog.Warnf("🚫 Reporting queue full, dropped summary of epoch %d", summary.EpochID)
+	}
+}
+
+// Stop stops the worker, spooling issues that are still queued
+func (s *ReportingService) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *ReportingService) run() {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	s.replaySpool(ctx)
+	replay := time.NewTicker(spoolReplayInterval)
+	defer replay.Stop()
+
+	for {
+		select {
+		case issue := <-s.queue:
+			if s.deliver(ctx, s.collect(issue)) && s.spool.pending() {
+				s.replaySpool(ctx)
+			}
+		case summary := <-s.summaries:
+			if err := s.postJSON(ctx, s.epochSummaryURL, summary); err != nil {
+				reportingLog.Warnf("⚠️ Failed to send summary of epoch %d: %v", summary.EpochID, err)
+			}
+		case <-replay.C:
+			s.replaySpool(ctx)
+		case <-s.stop:
+			s.drain()
+			return
+		}
+	}
+}
+
+// collect gathers a batch starting with first, waiting up to the batch
+// window for it to fill
+func (s *ReportingService) collect(first SnapshotterIssue) []SnapshotterIssue {
+	batch := []SnapshotterIssue{first}
+	if s.opts.batchSize <= 1 {
+		return batch
+	}
+
+	timer := time.NewTimer(s.opts.batchWindow)
+	defer timer.Stop()
+	for len(batch) < s.opts.batchSize {
+		select {
+		case issue := <-s.queue:
+			batch = append(batch, issue)
+		case <-timer.C:
+			return batch
+		case <-s.stop:
+			return batch
+		}
+	}
+	return batch
+}
+
+// drain spools whatever is left in the queue at shutdown
+func (s *ReportingService) drain() {
+	var rest []SnapshotterIssue
+	for {
+		select {
+		case issue := <-s.queue:
+			rest = append(rest, issue)
+		default:
+			s.spoolIssues(rest)
+			return
+		}
+	}
+}
+
+// deliver sends a batch and spools the issues that could not be delivered.
+// It reports whether every issue was delivered.
+func (s *ReportingService) deliver(ctx context.Context, batch []SnapshotterIssue) bool {
+	settled, delivered, err := s.send(ctx, batch)
+	if settled < len(batch) {
+		rest := batch[settled:]
+		reportingLog.Warnf("⚠️ Failed to report %d issue(s), spooling: %v", len(rest), err)
+		s.spoolIssues(rest)
+		return false
+	}
+	return delivered
+}
+
+// send posts the issues of a batch one at a time, each retried with backoff,
+// and stops at the first one that cannot be delivered. settled counts the
+// leading issues that need no further attempt, having been either sent or
+// rejected; delivered is false when any was rejected.
+func (s *ReportingService) send(ctx context.Context, batch []SnapshotterIssue) (settled int, delivered bool, err error) {
+	delivered = true
+	for i, issue := range batch {
+		b := backoff.NewExponentialBackOff()
+		b.MaxElapsedTime = s.opts.maxRetryTime
+		err := backoff.Retry(func() error {
+			return s.postJSON(ctx, s.url, issue)
+		}, backoff.WithContext(b, ctx))
+
+		switch {
+		case err == nil:
+			issueReports.WithLabelValues(string(issue.IssueType), "sent").Inc()
+		case errors.Is(err, errReportRejected):
+			issueReports.WithLabelValues(string(issue.IssueType), "rejected").Inc()
+			reportingLog.Errorf("❌ Reporting service rejected %s issue: %v", issue.IssueType, err)
+			delivered = false
+		default:
+			return i, false, err
+		}
+	}
+	reportingLog.Debugf("📣 Reported %d issue(s)", len(batch))
+	return len(batch), delivered, nil
+}
+
+// postJSON posts payload to url, signed when a signing key is configured
+func (s *ReportingService) postJSON(ctx context.Context, url string, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		log.Errorln("Error creating request: ", err)
+		return backoff.Permanent(fmt.Errorf("%w: unable to marshal: %v", errReportRejected, err))
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("%w: error creating request: %v", errReportRejected, err))
+	}
 	req.Header.Set("Content-Type", "application/json")
+	if s.signer != nil {
+		req.Header.Set(headerReportSignature, "0x"+hex.EncodeToString(s.signer.SignPersonalMessage(jsonData)))
+		req.Header.Set(headerReportSigner, s.signer.Address())
+	}
 
-	// Send the request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		log.Errorln("Error sending request: ", err)
-		// Handle error in case of failure
-		return
+		return fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	reportingLog.Debugln("Reporting service response status: ", resp.Status)
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("reporting service returned %s", resp.Status)
+	default:
+		return backoff.Permanent(fmt.Errorf("%w: reporting service returned %s", errReportRejected, resp.Status))
+	}
+}
 
-	// Here you can handle response or further actions
-	log.Debugln("Reporting service response status: ", resp.Status)
+// replaySpool retries spooled issues batch by batch. Issues leave the spool
+// only once they are settled, so a crash during the replay cannot lose them;
+// what still cannot be delivered stays for the next replay.
+func (s *ReportingService) replaySpool(ctx context.Context) {
+	snapshot, err := s.spool.read()
+	if err != nil {
+		reportingLog.Warnf("Failed to read reporting spool: %v", err)
+		return
+	}
+	if len(snapshot.issues) == 0 {
+		if snapshot.size > 0 {
+			s.removeSpooled(snapshot.size) // Only unreadable lines
+		}
+		return
+	}
+	reportingLog.Infof("📤 Replaying %d spooled issue(s)", len(snapshot.issues))
+
+	size := s.opts.batchSize
+	if size < 1 {
+		size = 1
+	}
+	var removed int64
+	for start := 0; start < len(snapshot.issues); start += size {
+		if ctx.Err() != nil {
+			return
+		}
+		end := min(start+size, len(snapshot.issues))
+		settled, _, err := s.send(ctx, snapshot.issues[start:end])
+		if settled > 0 {
+			upTo := snapshot.ends[start+settled-1]
+			if end == len(snapshot.issues) && settled == end-start {
+				upTo = snapshot.size // Trailing unreadable lines go too
+			}
+			if !s.removeSpooled(upTo - removed) {
+				return
+			}
+			removed = upTo
+		}
+		if settled < end-start {
+			reportingLog.Warnf("⚠️ Spooled issues still cannot be reported, keeping %d: %v",
+				len(snapshot.issues)-start-settled, err)
+			return
+		}
+	}
+}
+
+// removeSpooled drops the first n bytes of the spool, reporting success
+func (s *ReportingService) removeSpooled(n int64) bool {
+	if err := s.spool.remove(n); err != nil {
+		reportingLog.Warnf("Failed to remove replayed issues from the spool: %v", err)
+		return false
+	}
+	return true
+}
+
+func (s *ReportingService) spoolIssues(issues []SnapshotterIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	if err := s.spool.add(issues); err != nil {
+		for _, issue := range issues {
+			issueReports.WithLabelValues(string(issue.IssueType), "lost").Inc()
+		}
+		reportingLog.Errorf("❌ Lost %d issue(s): %v", len(issues), err)
+		return
+	}
+	for _, issue := range issues {
+		issueReports.WithLabelValues(string(issue.IssueType), "spooled").Inc()
+	}
 }
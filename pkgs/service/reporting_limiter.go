@@ -0,0 +1,90 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// issueLimiter caps how many reports of each issue type are sent a minute
+// and drops reports identical to one sent within the dedup window, so an
+// outage produces a handful of reports rather than one per failed request
+type issueLimiter struct {
+	mu          sync.Mutex
+	perMinute   int
+	dedupWindow time.Duration
+	windows     map[IssueType]*rateWindow
+	seen        map[string]time.Time
+	lastPrune   time.Time
+	now         func() time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Reasons an issue was not queued, used as metric labels
+const (
+	issueDropRateLimited = "rate_limited"
+	issueDropDuplicate   = "duplicate"
+)
+
+func newIssueLimiter(perMinute int, dedupWindow time.Duration) *issueLimiter {
+	return &issueLimiter{
+		perMinute:   perMinute,
+		dedupWindow: dedupWindow,
+		windows:     make(map[IssueType]*rateWindow),
+		seen:        make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// allow reports whether issue may be sent, and if not why
+func (l *issueLimiter) allow(issue SnapshotterIssue) (bool, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	key := issueKey(issue)
+	if l.dedupWindow > 0 {
+		if last, ok := l.seen[key]; ok && now.Sub(last) < l.dedupWindow {
+			return false, issueDropDuplicate
+		}
+	}
+
+	w := l.windows[issue.IssueType]
+	if w == nil || now.Sub(w.start) >= time.Minute {
+		w = &rateWindow{start: now}
+		l.windows[issue.IssueType] = w
+	}
+	if w.count >= l.perMinute {
+		return false, issueDropRateLimited
+	}
+	w.count++
+
+	if l.dedupWindow > 0 {
+		l.seen[key] = now
+	}
+	return true, ""
+}
+
+// prune forgets reports older than the dedup window, at most once per window
+func (l *issueLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.dedupWindow {
+		return
+	}
+	l.lastPrune = now
+	for key, last := range l.seen {
+		if now.Sub(last) >= l.dedupWindow {
+			delete(l.seen, key)
+		}
+	}
+}
+
+// issueKey identifies reports of the same problem, ignoring when they were
+// made and per-submission details such as IDs and error text
+func issueKey(issue SnapshotterIssue) string {
+	return string(issue.IssueType) + "|" + issue.ProjectID + "|" + issue.EpochID + "|" + issue.errorClass
+}
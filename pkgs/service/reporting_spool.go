@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// spoolMu serialises access to spool files, which outlive a reporting
+// service replaced on config reload
+var spoolMu sync.Mutex
+
+// issueSpool keeps issues that could not be delivered in a JSON lines file so
+// they can be sent once the reporting endpoint is back
+type issueSpool struct {
+	path     string // Empty disables spooling
+	maxBytes int64
+}
+
+// add appends issues to the spool, dropping them once the spool is full
+func (s *issueSpool) add(issues []SnapshotterIssue) error {
+	if s.path == "" || len(issues) == 0 {
+		return fmt.Errorf("spooling disabled")
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, issue := range issues {
+		if err := encoder.Encode(issue); err != nil {
+			return fmt.Errorf("failed to encode spooled issue: %w", err)
+		}
+	}
+
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
+
+	if info, err := os.Stat(s.path); err == nil && info.Size()+int64(buf.Len()) > s.maxBytes {
+		return fmt.Errorf("spool %s is full (%d bytes)", s.path, info.Size())
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open spool: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write spool: %w", err)
+	}
+	return nil
+}
+
+// spoolSnapshot is the content of the spool at the time it was read
+type spoolSnapshot struct {
+	issues []SnapshotterIssue
+	ends   []int64 // Offset just past the line of each issue
+	size   int64   // Bytes read
+}
+
+// read returns every spooled issue without removing any. Lines that cannot
+// be parsed are skipped.
+func (s *issueSpool) read() (spoolSnapshot, error) {
+	var snapshot spoolSnapshot
+	if s.path == "" {
+		return snapshot, nil
+	}
+
+	spoolMu.Lock()
+	data, err := os.ReadFile(s.path)
+	spoolMu.Unlock()
+	if errors.Is(err, fs.ErrNotExist) {
+		return snapshot, nil
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read spool: %w", err)
+	}
+
+	snapshot.size = int64(len(data))
+	var offset int64
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+		offset += int64(len(line))
+
+		var issue SnapshotterIssue
+		if err := json.Unmarshal(bytes.TrimSpace(line), &issue); err != nil {
+			reportingLog.Warnf("Skipping unreadable spooled issue: %v", err)
+			continue
+		}
+		snapshot.issues = append(snapshot.issues, issue)
+		snapshot.ends = append(snapshot.ends, offset)
+	}
+	return snapshot, nil
+}
+
+// remove drops the first n bytes of the spool, which hold issues that have
+// been replayed, keeping anything spooled since. The rest is written to a
+// temporary file that replaces the spool, so a crash leaves either the old
+// or the new spool in place.
+func (s *issueSpool) remove(n int64) error {
+	if s.path == "" || n <= 0 {
+		return nil
+	}
+
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read spool: %w", err)
+	}
+	if int64(len(data)) < n {
+		return fmt.Errorf("spool %s shrank to %d bytes while replaying %d", s.path, len(data), n)
+	}
+	rest := data[n:]
+	if len(rest) == 0 {
+		if err := os.Remove(s.path); err != nil {
+			return fmt.Errorf("failed to clear spool: %w", err)
+		}
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, rest, 0o600); err != nil {
+		return fmt.Errorf("failed to write spool: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace spool: %w", err)
+	}
+	return nil
+}
+
+// pending reports whether the spool holds any issues
+func (s *issueSpool) pending() bool {
+	if s.path == "" {
+		return false
+	}
+	spoolMu.Lock()
+	defer spoolMu.Unlock()
+	info, err := os.Stat(s.path)
+	return err == nil && info.Size() > 0
+}
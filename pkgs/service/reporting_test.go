@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"proto-snapshot-server/pkgs/helpers"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueLimiterDropsDuplicatesAndLimitsRate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := newIssueLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	issue := func(projectID string) SnapshotterIssue {
+		return SnapshotterIssue{IssueType: IssueSequencerDialFailure, ProjectID: projectID}
+	}
+
+	ok, _ := limiter.allow(issue("a"))
+	assert.True(t, ok)
+
+	ok, reason := limiter.allow(issue("a"))
+	assert.False(t, ok)
+	assert.Equal(t, issueDropDuplicate, reason)
+
+	// Per-submission details do not make a report distinct
+	useSettings(t, &config.Settings{})
+	err := errors.New("Write failed: stream reset")
+	first := newIssue(IssueStreamWriteFailure, &pkgs.Request{EpochId: 9}, err, IssueDetails{"submissionId": "one"})
+	second := newIssue(IssueStreamWriteFailure, &pkgs.Request{EpochId: 9}, err, IssueDetails{"submissionId": "two"})
+	assert.Equal(t, issueKey(first), issueKey(second))
+
+	ok, _ = limiter.allow(issue("b"))
+	assert.True(t, ok)
+
+	ok, reason = limiter.allow(issue("c"))
+	assert.False(t, ok)
+	assert.Equal(t, issueDropRateLimited, reason)
+
+	// Other issue types have their own budget
+	ok, _ = limiter.allow(SnapshotterIssue{IssueType: IssueStreamWriteFailure})
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	ok, _ = limiter.allow(issue("a"))
+	assert.True(t, ok, "duplicates are allowed again after the dedup window")
+}
+
+func TestReportingServiceSpoolsUntilEndpointRecovers(t *testing.T) {
+	var up atomic.Bool
+	var mu sync.Mutex
+	var received []SnapshotterIssue
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var issue SnapshotterIssue
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		mu.Lock()
+		received = append(received, issue)
+		mu.Unlock()
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{
+		queueSize:     10,
+		batchSize:     1,
+		batchWindow:   10 * time.Millisecond,
+		maxRetryTime:  50 * time.Millisecond,
+		ratePerMinute: 10,
+		spoolFile:     filepath.Join(t.TempDir(), "spool.jsonl"),
+		spoolMaxBytes: 1 << 20,
+	})
+	ctx := context.Background()
+
+	first := SnapshotterIssue{IssueType: IssueSequencerDialFailure, Extra: `{"n":1}`}
+	assert.False(t, reporter.deliver(ctx, []SnapshotterIssue{first}))
+	assert.True(t, reporter.spool.pending())
+
+	up.Store(true)
+	second := SnapshotterIssue{IssueType: IssueStreamWriteFailure, Extra: `{"n":2}`}
+	assert.True(t, reporter.deliver(ctx, []SnapshotterIssue{second}))
+	reporter.replaySpool(ctx)
+	assert.False(t, reporter.spool.pending())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []SnapshotterIssue{second, first}, received)
+}
+
+func TestReportingServiceDropsRejectedIssues(t *testing.T) {
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{
+		queueSize:     10,
+		batchSize:     1,
+		maxRetryTime:  time.Second,
+		ratePerMinute: 10,
+		spoolFile:     filepath.Join(t.TempDir(), "spool.jsonl"),
+		spoolMaxBytes: 1 << 20,
+	})
+
+	issue := SnapshotterIssue{IssueType: IssueSignatureMismatch}
+	assert.False(t, reporter.deliver(context.Background(), []SnapshotterIssue{issue}))
+	assert.False(t, reporter.spool.pending(), "rejected issues are not spooled")
+}
+
+func TestReportingServiceSignsReports(t *testing.T) {
+	signer, err := helpers.NewEthereumSigner("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	assert.NoError(t, err)
+
+	var signature, address string
+	var body []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(headerReportSignature)
+		address = r.Header.Get(headerReportSigner)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{queueSize: 1, batchSize: 1, maxRetryTime: time.Second, ratePerMinute: 1})
+	reporter.signer = signer
+
+	assert.True(t, reporter.deliver(context.Background(), []SnapshotterIssue{{IssueType: IssueStreamWriteFailure}}))
+	assert.Equal(t, signer.Address(), address)
+	assert.Equal(t, "0x"+hex.EncodeToString(signer.SignPersonalMessage(body)), signature)
+}
+
+func TestReportingServicePostsBatchedIssuesSingly(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var issue SnapshotterIssue
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&issue))
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{queueSize: 10, batchSize: 3, maxRetryTime: time.Second, ratePerMinute: 10})
+	batch := []SnapshotterIssue{
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":1}`},
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":2}`},
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":3}`},
+	}
+	assert.True(t, reporter.deliver(context.Background(), batch))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/reportIssue", "/reportIssue", "/reportIssue"}, paths)
+}
+
+func TestReportingServiceDropsIssuesReportedAfterStop(t *testing.T) {
+	reporter := newReportingService("http://127.0.0.1:1", time.Second, reportingOptions{
+		queueSize:     10,
+		batchSize:     1,
+		maxRetryTime:  10 * time.Millisecond,
+		ratePerMinute: 10,
+		spoolFile:     filepath.Join(t.TempDir(), "spool.jsonl"),
+		spoolMaxBytes: 1 << 20,
+	})
+	go reporter.run()
+	reporter.Stop()
+
+	reporter.Report(SnapshotterIssue{IssueType: IssueStreamWriteFailure})
+	assert.Zero(t, len(reporter.queue), "nothing is queued once stopped")
+	assert.False(t, reporter.spool.pending(), "the caller must not write the spool")
+}
+
+func TestReplaySpoolKeepsIssuesUntilDelivered(t *testing.T) {
+	var accepted atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Accept one issue, then go down
+		if accepted.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer endpoint.Close()
+
+	reporter := newReportingService(endpoint.URL, time.Second, reportingOptions{
+		queueSize:     10,
+		batchSize:     1,
+		maxRetryTime:  10 * time.Millisecond,
+		ratePerMinute: 10,
+		spoolFile:     filepath.Join(t.TempDir(), "spool.jsonl"),
+		spoolMaxBytes: 1 << 20,
+	})
+	issues := []SnapshotterIssue{
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":1}`},
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":2}`},
+		{IssueType: IssueStreamWriteFailure, Extra: `{"n":3}`},
+	}
+	assert.NoError(t, reporter.spool.add(issues))
+
+	// Reading the spool does not consume it
+	snapshot, err := reporter.spool.read()
+	assert.NoError(t, err)
+	assert.Equal(t, issues, snapshot.issues)
+
+	reporter.replaySpool(context.Background())
+	snapshot, err = reporter.spool.read()
+	assert.NoError(t, err)
+	assert.Equal(t, issues[1:], snapshot.issues, "only the delivered issue leaves the spool")
+}
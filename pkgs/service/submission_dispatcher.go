@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// queuedSubmission is a validated, marshalled submission waiting for a worker
+type queuedSubmission struct {
+	id         string
+	data       []byte
+	submission *pkgs.SnapshotSubmission
+	metrics    *epochMetrics
+	lane       submissionLane
+	enqueuedAt time.Time
+
+	spanContext trace.SpanContext // Span of the gRPC call that accepted the submission
+}
+
+// submissionDispatcher feeds accepted submissions from bounded in-memory
+// per-lane queues to a fixed pool of workers writing to the sequencer.
+// Workers drain the urgent lane first and the others by weight, so a flood of
+// late or simulation submissions cannot hold back current-epoch ones.
+type submissionDispatcher struct {
+	server  *server
+	workers sync.WaitGroup
+
+	mu        sync.Mutex
+	nonEmpty  *sync.Cond
+	lanes     [laneCount][]*queuedSubmission
+	queueSize int // Per-lane capacity
+	picker    *lanePicker
+	closed    bool
+}
+
+func newSubmissionDispatcher(s *server, queueSize int, weights [laneCount]int) *submissionDispatcher {
+	d := &submissionDispatcher{
+		server:    s,
+		queueSize: queueSize,
+		picker:    newLanePicker(weights),
+	}
+	d.nonEmpty = sync.NewCond(&d.mu)
+	return d
+}
+
+func (d *submissionDispatcher) start(workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go d.work(i)
+	}
+}
+
+// enqueue hands a submission to the worker pool without blocking
+func (d *submissionDispatcher) enqueue(item *queuedSubmission) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return status.Error(codes.Unavailable, "collector is shutting down")
+	}
+	if len(d.lanes[item.lane]) >= d.queueSize {
+		return status.Errorf(codes.ResourceExhausted, "%s submission queue full - try again later", item.lane)
+	}
+
+	d.lanes[item.lane] = append(d.lanes[item.lane], item)
+	d.nonEmpty.Signal()
+	return nil
+}
+
+// next blocks until a submission is available, returning false once the
+// dispatcher is stopped and every lane is drained. The submission is counted
+// as delivering before it leaves the queue, so Drain always sees it; the
+// worker releases it once it is handled.
+func (d *submissionDispatcher) next() (*queuedSubmission, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		lane, ok := d.picker.pick(func(l submissionLane) bool {
+			return len(d.lanes[l]) > 0
+		})
+		if ok {
+			d.server.delivering.Add(1)
+			item := d.lanes[lane][0]
+			d.lanes[lane][0] = nil
+			d.lanes[lane] = d.lanes[lane][1:]
+			return item, true
+		}
+		if d.closed {
+			return nil, false
+		}
+		d.nonEmpty.Wait()
+	}
+}
+
+func (d *submissionDispatcher) work(workerId int) {
+	defer d.workers.Done()
+
+	for {
+		item, ok := d.next()
+		if !ok {
+			return
+		}
+		d.handle(workerId, item)
+		d.server.delivering.Add(-1)
+	}
+}
+
+// handle writes a submission taken off the queue
+func (d *submissionDispatcher) handle(workerId int, item *queuedSubmission) {
+	grpcLog.Debugf("👷 Worker %d picked up %s submission %s after %v in queue",
+		workerId, item.lane, item.id, time.Since(item.enqueuedAt))
+	observeStage(stageQueue, item.enqueuedAt)
+
+	// Submissions can expire while queued
+	if config.Current().DeadlineAction == "reject" {
+		if err := checkDeadline(item.id, item.submission.Request); err != nil {
+			d.server.recordOutcome(item, err)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(
+		trace.ContextWithSpanContext(context.Background(), item.spanContext),
+		config.Current().SubmissionTimeout,
+	)
+	defer cancel()
+	if err := d.server.deliver(ctx, item); err != nil {
+		grpcLog.Errorf("❌ Async delivery failed for submission %s (Project: %s, Epoch: %d): %v",
+			item.id, item.submission.Request.ProjectId, item.submission.Request.EpochId, err)
+	}
+}
+
+// setQueueSize changes the per-lane capacity for new submissions
+func (d *submissionDispatcher) setQueueSize(queueSize int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queueSize = queueSize
+}
+
+// pending returns the number of queued submissions per lane
+func (d *submissionDispatcher) pending() [laneCount]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var counts [laneCount]int
+	for l, queue := range d.lanes {
+		counts[l] = len(queue)
+	}
+	return counts
+}
+
+// idle reports whether nothing is queued or being delivered. Both are read
+// under the queue lock, which also covers moving a submission from the
+// queue to a worker.
+func (d *submissionDispatcher) idle() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, queue := range d.lanes {
+		if len(queue) > 0 {
+			return false
+		}
+	}
+	return d.server.delivering.Load() == 0
+}
+
+// stop rejects further submissions and waits for the queue to drain
+func (d *submissionDispatcher) stop() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	d.nonEmpty.Broadcast()
+	d.mu.Unlock()
+
+	grpcLog.Infof("⏳ Draining queued submissions %v", d.pending())
+	d.workers.Wait()
+	grpcLog.Info("✅ Submission queue drained")
+}
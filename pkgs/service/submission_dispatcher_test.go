@@ -0,0 +1,91 @@
+package service
+
+import (
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestDispatcher(queueSize int) (*server, *submissionDispatcher) {
+	s := &server{epochs: newEpochTracker(4)}
+	s.dispatcher = newSubmissionDispatcher(s, queueSize,
+		[laneCount]int{laneCurrent: 1, laneLate: 1, laneSimulation: 1})
+	return s, s.dispatcher
+}
+
+func testSubmission(lane submissionLane, metrics *epochMetrics) *queuedSubmission {
+	return &queuedSubmission{
+		id:         "submission",
+		submission: &pkgs.SnapshotSubmission{Request: &pkgs.Request{EpochId: 1, Deadline: 50}},
+		metrics:    metrics,
+		lane:       lane,
+	}
+}
+
+func TestDispatcherRejectsWhenLaneIsFull(t *testing.T) {
+	_, d := newTestDispatcher(2)
+
+	assert.NoError(t, d.enqueue(testSubmission(laneCurrent, nil)))
+	assert.NoError(t, d.enqueue(testSubmission(laneCurrent, nil)))
+	err := d.enqueue(testSubmission(laneCurrent, nil))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// Lanes are bounded separately
+	assert.NoError(t, d.enqueue(testSubmission(laneLate, nil)))
+	assert.Equal(t, 2, d.pending()[laneCurrent])
+}
+
+func TestDispatcherRejectsAfterStop(t *testing.T) {
+	_, d := newTestDispatcher(2)
+	d.start(1)
+	d.stop()
+
+	err := d.enqueue(testSubmission(laneCurrent, nil))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestDispatcherStopDrainsQueue(t *testing.T) {
+	// Every submission is past its deadline, so workers settle it without a
+	// sequencer connection
+	useSettings(t, &config.Settings{DeadlineAction: "reject"})
+	useChainHead(t, NewStaticChainHead(100))
+
+	_, d := newTestDispatcher(10)
+	metrics := &epochMetrics{}
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, d.enqueue(testSubmission(laneCurrent, metrics)))
+	}
+	assert.NoError(t, d.enqueue(testSubmission(laneSimulation, metrics)))
+
+	d.start(2)
+	d.stop()
+
+	assert.Equal(t, [laneCount]int{}, d.pending())
+	assert.Equal(t, uint64(6), metrics.failed.Load())
+	assert.True(t, d.idle())
+}
+
+func TestDispatcherQueueShrink(t *testing.T) {
+	_, d := newTestDispatcher(3)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, d.enqueue(testSubmission(laneCurrent, nil)))
+	}
+
+	d.setQueueSize(1)
+	assert.Equal(t, 3, d.pending()[laneCurrent], "queued submissions are kept")
+	err := d.enqueue(testSubmission(laneCurrent, nil))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// New submissions are accepted once the lane is below the new size
+	for i := 0; i < 3; i++ {
+		_, ok := d.next()
+		assert.True(t, ok)
+	}
+	assert.NoError(t, d.enqueue(testSubmission(laneCurrent, nil)))
+	err = d.enqueue(testSubmission(laneCurrent, nil))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
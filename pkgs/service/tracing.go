@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// Tracing exporters selectable through TRACING_EXPORTER
+const (
+	TracingExporterOTLP = "otlp"
+	TracingExporterFile = "file"
+)
+
+const tracingServiceName = "snapshotter-local-collector"
+
+// tracer is a no-op until InitTracing installs a real provider
+var tracer = otel.Tracer("proto-snapshot-server/service")
+
+// InitTracing installs the global tracer provider and propagator. The returned
+// function flushes and stops the exporter.
+func InitTracing(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !config.Current().TracingEnabled {
+		return noop, nil
+	}
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch config.Current().TracingExporter {
+	case TracingExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Current().TracingEndpoint)}
+		if config.Current().TracingInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return noop, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = exp
+	case TracingExporterFile:
+		f, err := os.OpenFile(config.Current().TracingFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return noop, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return noop, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		exporter = exp
+		closeFile = f.Close
+	default:
+		return noop, fmt.Errorf("unknown tracing exporter %q", config.Current().TracingExporter)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(tracingServiceName))
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(float64(config.Current().TracingSamplePercent)/100),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	log.Infof("🔭 Tracing enabled with %s exporter", config.Current().TracingExporter)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			closeFile()
+		}
+		return err
+	}, nil
+}
+
+// metadataCarrier adapts incoming gRPC metadata to the propagation API
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// extractTraceContext continues a trace started by the gRPC caller, if any
+func extractTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// submissionAttributes describes a submission on a span
+func submissionAttributes(submissionId string, request *pkgs.Request) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("submission.id", submissionId),
+		attribute.Int64("submission.epoch_id", int64(request.EpochId)),
+		attribute.String("submission.project_id", request.ProjectId),
+		attribute.Int64("submission.slot_id", int64(request.SlotId)),
+	}
+}
+
+// endSpan records err, if any, on span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
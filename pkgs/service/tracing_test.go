@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"proto-snapshot-server/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTracingFileExporterHonoursIncomingTraceContext(t *testing.T) {
+	traceFile := filepath.Join(t.TempDir(), "traces.json")
+	useSettings(t, &config.Settings{
+		TracingEnabled:       true,
+		TracingExporter:      TracingExporterFile,
+		TracingFile:          traceFile,
+		TracingSamplePercent: 100,
+	})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	shutdown, err := InitTracing(context.Background())
+	assert.NoError(t, err)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-"+traceID+"-00f067aa0ba902b7-01",
+	))
+	ctx, span := tracer.Start(extractTraceContext(ctx), "SubmitSnapshot")
+	assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+
+	contents, err := os.ReadFile(traceFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), traceID)
+	assert.Contains(t, string(contents), "SubmitSnapshot")
+}
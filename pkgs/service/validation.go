@@ -0,0 +1,185 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"regexp"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Field size limits, well above anything a snapshotter legitimately sends
+const (
+	maxProjectIDLength   = 256
+	maxSnapshotCIDLength = 128
+	maxNodeVersionLength = 64
+)
+
+// Powerloom project IDs are <type>:<contract or hash>:<namespace>, e.g.
+// pairContract_trade_volume:0xabc...:UNISWAPV2
+var projectIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+:[A-Za-z0-9_.-]+:[A-Za-z0-9_.-]+$`)
+
+// Lengths in bytes of the hex-encoded submission fields
+const (
+	signatureLength = 65 // EIP-712 signature: r, s and v
+	headerLength    = 32 // Block hash
+	addressLength   = 20
+)
+
+// validateSubmission checks the shape of a submission before it is queued,
+// so malformed snapshotter output is caught here instead of by the
+// sequencer. The returned InvalidArgument status lists every violation as a
+// BadRequest field violation.
+func validateSubmission(submission *pkgs.SnapshotSubmission) error {
+	if submission == nil || submission.Request == nil {
+		return invalidSubmission([]*errdetails.BadRequest_FieldViolation{
+			violation("request", "is required"),
+		})
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	add := func(field, format string, args ...interface{}) {
+		violations = append(violations, violation(field, fmt.Sprintf(format, args...)))
+	}
+
+	if size, limit := proto.Size(submission), config.Current().MaxSubmissionBytes; limit > 0 && size > limit {
+		add("submission", "is %d bytes, more than the limit of %d", size, limit)
+	}
+
+	request := submission.Request
+	switch {
+	case request.ProjectId == "":
+		add("request.projectId", "is required")
+	case len(request.ProjectId) > maxProjectIDLength:
+		add("request.projectId", "is longer than %d bytes", maxProjectIDLength)
+	case !projectIDPattern.MatchString(request.ProjectId):
+		add("request.projectId", "%q is not of the form <type>:<contract>:<namespace>", request.ProjectId)
+	}
+
+	switch {
+	case request.SnapshotCid == "":
+		add("request.snapshotCid", "is required")
+	case len(request.SnapshotCid) > maxSnapshotCIDLength:
+		add("request.snapshotCid", "is longer than %d characters", maxSnapshotCIDLength)
+	default:
+		if _, err := cid.Decode(request.SnapshotCid); err != nil {
+			add("request.snapshotCid", "%q is not a valid CID: %v", request.SnapshotCid, err)
+		}
+	}
+
+	if problem := checkHex(submission.Signature, signatureLength); problem != "" {
+		add("signature", "%s", problem)
+	}
+	if problem := checkHex(submission.Header, headerLength); problem != "" {
+		add("header", "%s", problem)
+	}
+	if submission.DataMarket != "" {
+		if problem := checkHex(submission.DataMarket, addressLength); problem != "" {
+			add("dataMarket", "%s", problem)
+		}
+	}
+	if len(submission.GetNodeVersion()) > maxNodeVersionLength {
+		add("nodeVersion", "is longer than %d bytes", maxNodeVersionLength)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return invalidSubmission(violations)
+}
+
+// checkHex describes what is wrong with value as a hex encoding, with
+// optional 0x prefix, of exactly length bytes; it is empty when nothing is
+func checkHex(value string, length int) string {
+	if value == "" {
+		return "is required"
+	}
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if len(digits) != 2*length {
+		return fmt.Sprintf("must be %d hex-encoded bytes, got %d characters", length, len(digits))
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "is not hex-encoded"
+	}
+	return ""
+}
+
+func violation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+// invalidSubmission builds the InvalidArgument status for violations. The
+// message repeats them for clients that do not read status details.
+func invalidSubmission(violations []*errdetails.BadRequest_FieldViolation) error {
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Field + " " + v.Description
+	}
+	st := status.New(codes.InvalidArgument, "invalid submission: "+strings.Join(messages, "; "))
+	if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// checkSubmission validates a submission according to the validation action.
+// Malformed submissions are reported and, when the action is "reject",
+// refused; with "flag" they are still forwarded so snapshotter builds that
+// predate the checks keep working. A submission without a request is always
+// refused as there is nothing to forward.
+func checkSubmission(submission *pkgs.SnapshotSubmission) error {
+	action := config.Current().ValidationAction
+	if action == "off" && submission.GetRequest() != nil {
+		return nil
+	}
+	err := validateSubmission(submission)
+	if err == nil {
+		return nil
+	}
+
+	reject := action == "reject" || submission.GetRequest() == nil
+	label := "flagged"
+	if reject {
+		label = "rejected"
+	}
+	submissionsInvalid.WithLabelValues(label).Inc()
+	grpcLog.WithError(err).Warnf("🚫 Malformed submission %s", label)
+	reportInvalidSubmission(submission, err, label)
+
+	if reject {
+		return err
+	}
+	return nil
+}
+
+// reportInvalidSubmission reports a malformed submission so broken snapshotter
+// builds show up in the reporting service and webhooks
+func reportInvalidSubmission(submission *pkgs.SnapshotSubmission, err error, action string) {
+	violations := make(map[string]string)
+	for _, v := range fieldViolations(err) {
+		violations[v.Field] = v.Description
+	}
+	details := IssueDetails{"violations": violations, "action": action}
+	if nodeVersion := submission.GetNodeVersion(); nodeVersion != "" {
+		details["nodeVersion"] = nodeVersion
+	}
+	reportIssue(IssueInvalidSubmission, submission.GetRequest(), nil, details)
+}
+
+// fieldViolations returns the BadRequest field violations carried by err
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, badRequest.GetFieldViolations()...)
+		}
+	}
+	return violations
+}
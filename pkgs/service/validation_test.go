@@ -0,0 +1,101 @@
+package service
+
+import (
+	"proto-snapshot-server/config"
+	"proto-snapshot-server/pkgs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func validSubmission() *pkgs.SnapshotSubmission {
+	return &pkgs.SnapshotSubmission{
+		Request: &pkgs.Request{
+			SlotId:      1,
+			EpochId:     42,
+			ProjectId:   "pairContract_trade_volume:0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc:UNISWAPV2",
+			SnapshotCid: "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku",
+		},
+		Signature:  "0x" + strings.Repeat("ab", 65),
+		Header:     "0x" + strings.Repeat("cd", 32),
+		DataMarket: "0x0c2e22fe7526fafbde0c2e5e1e1c80b0c0d2f1a3",
+	}
+}
+
+func TestValidateSubmissionAcceptsWellFormed(t *testing.T) {
+	useSettings(t, &config.Settings{MaxSubmissionBytes: 8192})
+	assert.NoError(t, validateSubmission(validSubmission()))
+
+	v0 := validSubmission()
+	v0.Request.SnapshotCid = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	v0.Signature = strings.Repeat("ab", 65)
+	assert.NoError(t, validateSubmission(v0))
+}
+
+func TestValidateSubmissionReportsFieldViolations(t *testing.T) {
+	useSettings(t, &config.Settings{MaxSubmissionBytes: 8192})
+
+	err := validateSubmission(&pkgs.SnapshotSubmission{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "request", fieldViolations(err)[0].Field)
+
+	bad := validSubmission()
+	bad.Request.ProjectId = "not a project"
+	bad.Request.SnapshotCid = "bafy-not-a-cid"
+	bad.Signature = "0x1234"
+	bad.Header = "0x" + strings.Repeat("zz", 32)
+	err = validateSubmission(bad)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var fields []string
+	for _, v := range fieldViolations(err) {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{"request.projectId", "request.snapshotCid", "signature", "header"}, fields)
+
+	config.Current().MaxSubmissionBytes = 64
+	err = validateSubmission(validSubmission())
+	assert.Equal(t, "submission", fieldViolations(err)[0].Field)
+}
+
+// simulationSubmission is what snapshotter-lite sends for its startup
+// simulation run: epoch 0, a placeholder CID and no block header
+func simulationSubmission() *pkgs.SnapshotSubmission {
+	return &pkgs.SnapshotSubmission{
+		Request: &pkgs.Request{
+			SlotId:      1,
+			Deadline:    12345,
+			SnapshotCid: "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+			EpochId:     0,
+			ProjectId:   "test:0x0000000000000000000000000000000000000000:simulation",
+		},
+		Signature:   "0x" + strings.Repeat("ab", 65),
+		Header:      "",
+		DataMarket:  "0x0c2e22fe7526fafbde0c2e5e1e1c80b0c0d2f1a3",
+		NodeVersion: proto.String("v0.1.0"),
+	}
+}
+
+func TestCheckSubmissionFollowsValidationAction(t *testing.T) {
+	settings := &config.Settings{MaxSubmissionBytes: 8192, ValidationAction: "flag"}
+	useSettings(t, settings)
+
+	err := validateSubmission(simulationSubmission())
+	assert.Equal(t, "header", fieldViolations(err)[0].Field)
+	assert.NoError(t, checkSubmission(simulationSubmission()), "flagged submissions are forwarded")
+
+	settings.ValidationAction = "off"
+	assert.NoError(t, checkSubmission(simulationSubmission()))
+
+	settings.ValidationAction = "reject"
+	err = checkSubmission(simulationSubmission())
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Without a request there is nothing to forward, whatever the action
+	settings.ValidationAction = "off"
+	assert.Error(t, checkSubmission(&pkgs.SnapshotSubmission{}))
+}
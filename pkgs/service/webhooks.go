@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"proto-snapshot-server/config"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// webhookInstance delivers issues to the configured webhooks; nil when none
+// are configured
+var webhookInstance atomic.Pointer[webhookNotifier]
+
+// webhookNotifier sends issues to the operator's own endpoints from a
+// background worker. It shares the rate limits of the reporting service but
+// does not spool: alerts that cannot be delivered are logged and dropped.
+type webhookNotifier struct {
+	sinks        []*webhookSink
+	client       *http.Client
+	maxRetryTime time.Duration
+	queue        chan SnapshotterIssue
+	limiter      *issueLimiter
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+type webhookSink struct {
+	name        string
+	url         string
+	contentType string
+	headers     map[string]string
+	body        *template.Template // nil sends the issue as JSON
+	types       map[IssueType]bool // empty accepts every type
+	minSeverity Severity
+}
+
+// webhookPayload is what webhook templates render: the issue fields plus its
+// severity, the decoded Extra details and the collector version
+type webhookPayload struct {
+	SnapshotterIssue
+	Severity string
+	Details  map[string]interface{}
+	Version  string
+}
+
+// InitializeWebhooks starts delivering issues to the webhooks in settings,
+// replacing any that are running
+func InitializeWebhooks(settings *config.Settings) error {
+	var sinks []*webhookSink
+	for _, cfg := range settings.Webhooks {
+		sink, err := newWebhookSink(cfg)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", cfg.Name, err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	StopWebhooks()
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	notifier := &webhookNotifier{
+		sinks:        sinks,
+		client:       &http.Client{Timeout: settings.ReportingTimeout},
+		maxRetryTime: settings.ReportingMaxRetryTime,
+		queue:        make(chan SnapshotterIssue, settings.ReportingQueueSize),
+		limiter:      newIssueLimiter(settings.ReportingRateLimit, settings.ReportingDedupWindow),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	webhookInstance.Store(notifier)
+	go notifier.run()
+	reportingLog.Infof("🪝 Delivering issues to %d webhook(s)", len(sinks))
+	return nil
+}
+
+// StopWebhooks stops webhook delivery, dropping queued issues
+func StopWebhooks() {
+	if notifier := webhookInstance.Swap(nil); notifier != nil {
+		notifier.stopOnce.Do(func() { close(notifier.stop) })
+		<-notifier.done
+		if dropped := len(notifier.queue); dropped > 0 {
+			reportingLog.Warnf("Dropped %d queued webhook notification(s) on stop", dropped)
+		}
+	}
+}
+
+func newWebhookSink(cfg config.WebhookSink) (*webhookSink, error) {
+	body, err := cfg.ParseTemplate()
+	if err != nil {
+		return nil, err
+	}
+	sink := &webhookSink{
+		name:        cfg.Name,
+		url:         cfg.URL,
+		contentType: cfg.ContentType,
+		headers:     cfg.Headers,
+		body:        body,
+		types:       make(map[IssueType]bool, len(cfg.IssueTypes)),
+		minSeverity: parseSeverity(cfg.MinSeverity),
+	}
+	if sink.contentType == "" {
+		sink.contentType = "application/json"
+	}
+	for _, name := range cfg.IssueTypes {
+		issueType := IssueType(name)
+		if _, known := issueSeverities[issueType]; !known {
+			reportingLog.Warnf("⚠️ Webhook %s filters on unknown issue type %s", cfg.Name, name)
+		}
+		sink.types[issueType] = true
+	}
+	return sink, nil
+}
+
+// accepts reports whether the sink wants issues of this type
+func (s *webhookSink) accepts(issueType IssueType) bool {
+	if len(s.types) > 0 && !s.types[issueType] {
+		return false
+	}
+	return issueType.Severity() >= s.minSeverity
+}
+
+// render builds the request body for issue
+func (s *webhookSink) render(issue SnapshotterIssue) ([]byte, error) {
+	if s.body == nil {
+		return json.Marshal(issue)
+	}
+	payload := webhookPayload{
+		SnapshotterIssue: issue,
+		Severity:         issue.IssueType.Severity().String(),
+		Version:          config.Version,
+	}
+	if err := json.Unmarshal([]byte(issue.Extra), &payload.Details); err != nil {
+		payload.Details = map[string]interface{}{}
+	}
+	var buf bytes.Buffer
+	if err := s.body.Execute(&buf, payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// notify queues an issue without blocking, if any sink wants it
+func (n *webhookNotifier) notify(issue SnapshotterIssue) {
+	wanted := false
+	for _, sink := range n.sinks {
+		wanted = wanted || sink.accepts(issue.IssueType)
+	}
+	if !wanted {
+		return
+	}
+	if ok, reason := n.limiter.allow(issue); !ok {
+		reportingLog.Debugf("Dropped %s webhook notification: %s", issue.IssueType, reason)
+		return
+	}
+
+	select {
+	case n.queue <- issue:
+	default:
+		reportingLog.Warnf("🚫 Webhook queue full, dropped %s issue", issue.IssueType)
+	}
+}
+
+func (n *webhookNotifier) run() {
+	defer close(n.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-n.stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case issue := <-n.queue:
+			for _, sink := range n.sinks {
+				if sink.accepts(issue.IssueType) {
+					n.send(ctx, sink, issue)
+				}
+			}
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// send delivers issue to sink, retrying server errors with backoff
+func (n *webhookNotifier) send(ctx context.Context, sink *webhookSink, issue SnapshotterIssue) {
+	body, err := sink.render(issue)
+	if err != nil {
+		reportingLog.Errorf("❌ Failed to render webhook %s for %s issue: %v", sink.name, issue.IssueType, err)
+		return
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = n.maxRetryTime
+	err = backoff.Retry(func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, sink.url, bytes.NewReader(body))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		req.Header.Set("Content-Type", sink.contentType)
+		for name, value := range sink.headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		default:
+			return backoff.Permanent(fmt.Errorf("webhook returned %s", resp.Status))
+		}
+	}, backoff.WithContext(b, ctx))
+
+	if err != nil {
+		reportingLog.Warnf("⚠️ Failed to deliver %s issue to webhook %s: %v", issue.IssueType, sink.name, err)
+		return
+	}
+	reportingLog.Debugf("🪝 Delivered %s issue to webhook %s", issue.IssueType, sink.name)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"proto-snapshot-server/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSinkFiltersBySeverityAndType(t *testing.T) {
+	critical, err := newWebhookSink(config.WebhookSink{Name: "pager", URL: "http://example.com", MinSeverity: "critical"})
+	assert.NoError(t, err)
+	assert.True(t, critical.accepts(IssueSequencerDialFailure))
+	assert.False(t, critical.accepts(IssueStreamWriteFailure))
+
+	typed, err := newWebhookSink(config.WebhookSink{Name: "writes", URL: "http://example.com",
+		IssueTypes: []string{string(IssueStreamWriteFailure)}})
+	assert.NoError(t, err)
+	assert.True(t, typed.accepts(IssueStreamWriteFailure))
+	assert.False(t, typed.accepts(IssueSequencerDialFailure))
+}
+
+func TestWebhookSinkRendersTemplate(t *testing.T) {
+	var body []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer endpoint.Close()
+
+	sink, err := newWebhookSink(config.WebhookSink{
+		Name:     "slack",
+		URL:      endpoint.URL,
+		Template: `{"text": {{ printf "[%s] %s epoch %s: %v" .Severity .IssueType .EpochID .Details.issueDetails | json }}}`,
+	})
+	assert.NoError(t, err)
+
+	notifier := &webhookNotifier{client: endpoint.Client(), maxRetryTime: time.Second}
+	notifier.send(context.Background(), sink, SnapshotterIssue{
+		IssueType: IssueSequencerDialFailure,
+		EpochID:   "42",
+		Extra:     `{"issueDetails":"dial \"backoff\""}`,
+	})
+
+	assert.JSONEq(t, `{"text": "[critical] SEQUENCER_DIAL_FAILURE epoch 42: dial \"backoff\""}`, string(body))
+}